@@ -0,0 +1,236 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrEventNotFound is returned by GetEvent when the calendar has no event
+// with that id.
+var ErrEventNotFound = errors.New("event not found")
+
+// StatusError is a write the calendar answered with an error status.
+type StatusError struct {
+	Request string
+	Code    int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s failed with status code %d", e.Request, e.Code)
+}
+
+// isUnauthorized reports whether err means the access token has expired.
+func isUnauthorized(err error) bool {
+	var statusErr *StatusError
+	return errors.As(err, &statusErr) && statusErr.Code == http.StatusUnauthorized
+}
+
+// isPermanentError reports whether the calendar refused a write for good,
+// like an event that no longer exists, so sending it again cannot help.
+func isPermanentError(err error) bool {
+	if errors.Is(err, ErrEventNotFound) {
+		return true
+	}
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) {
+		return false
+	}
+	switch statusErr.Code {
+	case http.StatusUnauthorized, http.StatusRequestTimeout, http.StatusTooManyRequests:
+		return false
+	}
+	return statusErr.Code >= 400 && statusErr.Code < 500
+}
+
+// CalendarBackend is everything the TUI needs from a calendar provider.
+// ListEvents returns the events of every visible calendar.
+type CalendarBackend interface {
+	ListEvents(timeMin time.Time, timeMax time.Time) ([]Event, error)
+	CreateEvent(event Event) (Event, error)
+	UpdateEvent(event Event) (Event, error)
+	DeleteEvent(event Event) error
+	// MoveEvent moves event to the calendar destination
+	MoveEvent(event Event, destination string) (Event, error)
+	// RespondToEvent sends the response set on the self attendee of event
+	RespondToEvent(event Event) (Event, error)
+	GetEvent(calendarID string, eventID string) (Event, error)
+	// FreeBusy returns when any visible calendar is busy
+	FreeBusy(timeMin time.Time, timeMax time.Time) ([]TimeRange, error)
+	// SearchEvents returns the events of every visible calendar matching text
+	SearchEvents(text string, timeMin time.Time, timeMax time.Time) ([]Event, error)
+	ListCalendars() ([]Calendar, error)
+	SetCalendarVisible(calendarID string, visible bool) error
+	Refresh() error
+}
+
+// CachedBackend is implemented by backends that keep a local copy of the
+// calendar which can be shown before the first fetch finishes.
+type CachedBackend interface {
+	CachedEvents(timeMin time.Time, timeMax time.Time) []Event
+	CachedCalendars() []Calendar
+}
+
+// GoogleBackend talks to the Google Calendar v3 API.
+type GoogleBackend struct {
+	mu     sync.Mutex
+	config *apiConfig
+	cache  *EventCache
+}
+
+func NewGoogleBackend(config *apiConfig, cache *EventCache) *GoogleBackend {
+	return &GoogleBackend{config: config, cache: cache}
+}
+
+func (b *GoogleBackend) visibleCalendars() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]string(nil), b.config.visibleCalendars...)
+}
+
+// currentConfig copies the config under the lock, as showing or hiding a
+// calendar and refreshing the token change it while requests are made.
+func (b *GoogleBackend) currentConfig() apiConfig {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return *b.config
+}
+
+func (b *GoogleBackend) ListEvents(timeMin time.Time, timeMax time.Time) ([]Event, error) {
+	var events []Event
+	config := b.currentConfig()
+	visible := config.visibleCalendars
+	for _, calendarID := range visible {
+		if b.cache == nil {
+			calendarEvents, err := GetEvents(config, calendarID, timeMin, timeMax)
+			if err != nil {
+				return nil, err
+			}
+			events = append(events, calendarEvents...)
+			continue
+		}
+		err := SyncEvents(config, b.cache, calendarID, timeMin, timeMax)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if b.cache != nil {
+		events = b.cache.EventsBetween(visible, timeMin, timeMax)
+	}
+	SortEvents(events)
+	return events, nil
+}
+
+func (b *GoogleBackend) CachedEvents(timeMin time.Time, timeMax time.Time) []Event {
+	if b.cache == nil {
+		return nil
+	}
+	return b.cache.EventsBetween(b.visibleCalendars(), timeMin, timeMax)
+}
+
+func (b *GoogleBackend) ListCalendars() ([]Calendar, error) {
+	calendars, err := GetCalendarList(b.currentConfig())
+	if err != nil {
+		return nil, err
+	}
+	if b.cache != nil {
+		b.cache.SetCalendarList(calendars)
+		err = b.cache.Save()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return calendars, nil
+}
+
+func (b *GoogleBackend) CachedCalendars() []Calendar {
+	if b.cache == nil {
+		return nil
+	}
+	calendars := b.cache.CalendarListCopy()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for i := range calendars {
+		calendars[i].Visible = b.config.IsVisible(calendars[i].Id)
+	}
+	return calendars
+}
+
+func (b *GoogleBackend) SetCalendarVisible(calendarID string, visible bool) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var ids []string
+	for _, id := range b.config.visibleCalendars {
+		if id != calendarID {
+			ids = append(ids, id)
+		}
+	}
+	if visible {
+		ids = append(ids, calendarID)
+	}
+	b.config.visibleCalendars = ids
+	return SaveVisibleCalendars(ids)
+}
+
+func (b *GoogleBackend) TimeZone() string {
+	if b.cache == nil {
+		return ""
+	}
+	return b.cache.TimeZone(b.currentConfig().calendarID)
+}
+
+func (b *GoogleBackend) CreateEvent(event Event) (Event, error) {
+	return PostEvent(event, b.currentConfig())
+}
+
+func (b *GoogleBackend) UpdateEvent(event Event) (Event, error) {
+	return UpdateEvent(event, b.currentConfig())
+}
+
+func (b *GoogleBackend) DeleteEvent(event Event) error {
+	return DeleteEvent(event, b.currentConfig())
+}
+
+func (b *GoogleBackend) MoveEvent(event Event, destination string) (Event, error) {
+	return MoveEvent(event, destination, b.currentConfig())
+}
+
+func (b *GoogleBackend) RespondToEvent(event Event) (Event, error) {
+	return RespondToEvent(event, b.currentConfig())
+}
+
+func (b *GoogleBackend) GetEvent(calendarID string, eventID string) (Event, error) {
+	return GetEvent(b.currentConfig(), calendarID, eventID)
+}
+
+func (b *GoogleBackend) FreeBusy(timeMin time.Time, timeMax time.Time) ([]TimeRange, error) {
+	return GetFreeBusy(b.currentConfig(), b.visibleCalendars(), timeMin, timeMax)
+}
+
+func (b *GoogleBackend) SearchEvents(text string, timeMin time.Time, timeMax time.Time) ([]Event, error) {
+	var events []Event
+	config := b.currentConfig()
+	for _, calendarID := range config.visibleCalendars {
+		results, err := GetSearchResults(config, calendarID, text, timeMin, timeMax)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, results...)
+	}
+	SortEvents(events)
+	return events, nil
+}
+
+func (b *GoogleBackend) Refresh() error {
+	config := b.currentConfig()
+	err := RefreshOauth(&config)
+	if err != nil {
+		return err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.config.accessToken = config.accessToken
+	return nil
+}
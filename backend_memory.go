@@ -0,0 +1,301 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemoryBackend keeps events in process memory. It is used for demos and
+// for driving the TUI without a Google account.
+type MemoryBackend struct {
+	mu        sync.Mutex
+	events    map[string]Event
+	calendars []Calendar
+	nextID    int
+	// Occurrences of recurring events that were edited or cancelled
+	exceptions map[string]Event
+	cancelled  map[string]bool
+}
+
+// NewMemoryBackend serves events from the given calendars. The first
+// calendar is the default one and events without a calendar land there.
+func NewMemoryBackend(calendars []Calendar, events []Event) *MemoryBackend {
+	b := &MemoryBackend{
+		events:     make(map[string]Event),
+		calendars:  calendars,
+		exceptions: make(map[string]Event),
+		cancelled:  make(map[string]bool),
+	}
+	if len(b.calendars) == 0 {
+		b.calendars = []Calendar{{Id: "primary", Summary: "Calendar", Visible: true}}
+	}
+	b.calendars[0].Default = true
+	for _, event := range events {
+		if event.Id == "" {
+			event.Id = b.newID()
+		}
+		event = b.withCalendar(event)
+		b.events[event.Id] = normalizeEvent(event)
+	}
+	return b
+}
+
+func (b *MemoryBackend) withCalendar(event Event) Event {
+	if event.CalendarID == "" {
+		event.CalendarID = b.calendars[0].Id
+	}
+	return event
+}
+
+func (b *MemoryBackend) isVisible(calendarID string) bool {
+	for _, calendar := range b.calendars {
+		if calendar.Id == calendarID {
+			return calendar.Visible
+		}
+	}
+	return false
+}
+
+func (b *MemoryBackend) newID() string {
+	b.nextID++
+	return fmt.Sprintf("mem-%d", b.nextID)
+}
+
+func (b *MemoryBackend) ListEvents(timeMin time.Time, timeMax time.Time) ([]Event, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var events []Event
+	for _, event := range b.events {
+		if !b.isVisible(event.CalendarID) {
+			continue
+		}
+		if len(event.Recurrence) > 0 {
+			for _, instance := range ExpandRecurrence(event, timeMin, timeMax) {
+				if b.cancelled[instance.Id] {
+					continue
+				}
+				if exception, ok := b.exceptions[instance.Id]; ok {
+					instance = exception
+				}
+				events = append(events, instance)
+			}
+			continue
+		}
+		if event.End.DateTime.After(timeMin) && event.Start.DateTime.Before(timeMax) {
+			events = append(events, event)
+		}
+	}
+	SortEvents(events)
+	return events, nil
+}
+
+func (b *MemoryBackend) RespondToEvent(event Event) (Event, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	stored, ok := b.events[event.Id]
+	if !ok {
+		if !b.isOccurrence(event) {
+			return Event{}, fmt.Errorf("%w: %q", ErrEventNotFound, event.Id)
+		}
+		stored = event
+		if exception, ok := b.exceptions[event.Id]; ok {
+			stored = exception
+		}
+		stored.Attendees = event.Attendees
+		b.exceptions[event.Id] = stored
+		return stored, nil
+	}
+	stored.Attendees = event.Attendees
+	b.events[event.Id] = stored
+	return stored, nil
+}
+
+func (b *MemoryBackend) GetEvent(calendarID string, eventID string) (Event, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if event, ok := b.events[eventID]; ok {
+		return event, nil
+	}
+	if exception, ok := b.exceptions[eventID]; ok {
+		return exception, nil
+	}
+	// Occurrences of a series are expanded from the master on demand
+	if i := strings.LastIndex(eventID, "_"); i > 0 && !b.cancelled[eventID] {
+		if master, ok := b.events[eventID[:i]]; ok {
+			start := InstanceStart(Event{Id: eventID})
+			for _, instance := range ExpandRecurrence(master, start.Add(-time.Second), start.Add(time.Second)) {
+				if instance.Id == eventID {
+					return instance, nil
+				}
+			}
+		}
+	}
+	return Event{}, fmt.Errorf("%w: %q", ErrEventNotFound, eventID)
+}
+
+func (b *MemoryBackend) FreeBusy(timeMin time.Time, timeMax time.Time) ([]TimeRange, error) {
+	events, err := b.ListEvents(timeMin, timeMax)
+	if err != nil {
+		return nil, err
+	}
+	return BusyFromEvents(events), nil
+}
+
+func (b *MemoryBackend) SearchEvents(text string, timeMin time.Time, timeMax time.Time) ([]Event, error) {
+	events, err := b.ListEvents(timeMin, timeMax)
+	if err != nil {
+		return nil, err
+	}
+	return FilterEvents(events, text), nil
+}
+
+// isOccurrence reports whether id names an occurrence of a stored series.
+func (b *MemoryBackend) isOccurrence(event Event) bool {
+	_, ok := b.events[event.RecurringEventId]
+	return ok && event.RecurringEventId != "" && event.Id != event.RecurringEventId
+}
+
+func (b *MemoryBackend) CreateEvent(event Event) (Event, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	event.Id = b.newID()
+	event = withConference(normalizeEvent(b.withCalendar(event)))
+	b.events[event.Id] = event
+	return event, nil
+}
+
+func (b *MemoryBackend) UpdateEvent(event Event) (Event, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	event = withConference(normalizeEvent(b.withCalendar(event)))
+	if _, ok := b.events[event.Id]; !ok {
+		if !b.isOccurrence(event) {
+			return Event{}, fmt.Errorf("%w: %q", ErrEventNotFound, event.Id)
+		}
+		b.exceptions[event.Id] = event
+		return event, nil
+	}
+	b.events[event.Id] = event
+	return event, nil
+}
+
+func (b *MemoryBackend) MoveEvent(event Event, destination string) (Event, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	stored, ok := b.events[event.Id]
+	if !ok {
+		return Event{}, fmt.Errorf("%w: %q", ErrEventNotFound, event.Id)
+	}
+	stored.CalendarID = destination
+	b.events[event.Id] = stored
+	return stored, nil
+}
+
+func (b *MemoryBackend) DeleteEvent(event Event) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.events[event.Id]; !ok {
+		if !b.isOccurrence(event) {
+			return fmt.Errorf("%w: %q", ErrEventNotFound, event.Id)
+		}
+		b.cancelled[event.Id] = true
+		return nil
+	}
+	delete(b.events, event.Id)
+	return nil
+}
+
+func (b *MemoryBackend) ListCalendars() ([]Calendar, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]Calendar(nil), b.calendars...), nil
+}
+
+func (b *MemoryBackend) SetCalendarVisible(calendarID string, visible bool) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for i := range b.calendars {
+		if b.calendars[i].Id == calendarID {
+			b.calendars[i].Visible = visible
+			return nil
+		}
+	}
+	return fmt.Errorf("calendar %q not found", calendarID)
+}
+
+func (b *MemoryBackend) Refresh() error {
+	return nil
+}
+
+// normalizeEvent fills the derived DateTime fields the same way
+// ParseCalendarItem does for events coming from Google.
+func normalizeEvent(event Event) Event {
+	_, startZone := event.Start.DateTime.Zone()
+	_, endZone := event.End.DateTime.Zone()
+	event.Start.Date = event.Start.DateTime.Format(time.DateOnly)
+	event.Start.TimeZone = startZone
+	event.End.Date = event.End.DateTime.Format(time.DateOnly)
+	event.End.TimeZone = endZone
+	return event
+}
+
+// withConference stands in for Google creating a Meet link, and drops the
+// write options that only matter to the real API.
+func withConference(event Event) Event {
+	if event.AddConference && ConferenceLink(event) == "" {
+		code := "demo-" + event.Id
+		event.Conference = Conference{Name: "Google Meet", EntryPoints: []EntryPoint{
+			{Type: "video", URI: "https://meet.google.com/" + code, Label: "meet.google.com/" + code},
+		}}
+	}
+	event.AddConference = false
+	event.SendUpdates = ""
+	return event
+}
+
+func DemoCalendars() []Calendar {
+	return []Calendar{
+		{Id: "personal", Summary: "Personal", Color: "#7e9cd8", Visible: true, DefaultReminders: []Reminder{{Method: "popup", Minutes: 10}}},
+		{Id: "work", Summary: "Work", Color: "#98bb6c", Visible: true, DefaultReminders: []Reminder{{Method: "popup", Minutes: 10}, {Method: "email", Minutes: 24 * 60}}},
+		{Id: "team", Summary: "Team", Color: "#e6c384", Visible: false},
+	}
+}
+
+// DemoEvents returns a small week of made up events relative to today.
+func DemoEvents() []Event {
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	at := func(day int, hour int, minute int) time.Time {
+		return today.AddDate(0, 0, day).Add(time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute)
+	}
+	return []Event{
+		{Summary: "Standup", Start: DateTime{DateTime: at(0, 9, 30)}, End: DateTime{DateTime: at(0, 9, 45)}, Location: "Room 4", CalendarID: "work", Recurrence: []string{"RRULE:FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR"}},
+		{Summary: "Lunch with Sam", Start: DateTime{DateTime: at(0, 12, 0)}, End: DateTime{DateTime: at(0, 13, 0)}, Location: "Cafe"},
+		{
+			Summary: "Design review", Start: DateTime{DateTime: at(1, 14, 0)}, End: DateTime{DateTime: at(1, 15, 30)}, CalendarID: "work",
+			Description: "Walk through the new onboarding flow.<br>Bring your <b>notes</b> from last week.",
+			Organizer:   Person{Email: "alex@example.com", DisplayName: "Alex"},
+			Attendees: []Attendee{
+				{Email: "alex@example.com", DisplayName: "Alex", Organizer: true, ResponseStatus: "accepted"},
+				{Email: "me@example.com", Self: true, ResponseStatus: "needsAction"},
+				{Email: "kim@example.com", DisplayName: "Kim", Optional: true, ResponseStatus: "tentative"},
+			},
+			Conference: Conference{Name: "Google Meet", EntryPoints: []EntryPoint{{Type: "video", URI: "https://meet.google.com/abc-defg-hij", Label: "meet.google.com/abc-defg-hij"}}},
+		},
+		{Summary: "Team offsite planning", Start: DateTime{DateTime: at(1, 16, 0)}, End: DateTime{DateTime: at(1, 17, 0)}, CalendarID: "team"},
+		{Summary: "Company holiday", AllDay: true, Start: DateTime{DateTime: at(2, 0, 0)}, End: DateTime{DateTime: at(3, 0, 0)}, CalendarID: "work"},
+		{Summary: "Out of office", AllDay: true, Start: DateTime{DateTime: at(4, 0, 0)}, End: DateTime{DateTime: at(7, 0, 0)}},
+		{Summary: "Dentist", Start: DateTime{DateTime: at(2, 8, 0)}, End: DateTime{DateTime: at(2, 9, 0)}, Location: "Main St"},
+		{Summary: "Gym", Start: DateTime{DateTime: at(4, 18, 0)}, End: DateTime{DateTime: at(4, 19, 0)}},
+		{Summary: "Groceries", Start: DateTime{DateTime: at(5, 10, 0)}, End: DateTime{DateTime: at(5, 11, 0)}},
+	}
+}
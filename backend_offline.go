@@ -0,0 +1,360 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// ErrOffline is returned by OfflineBackend.ListEvents together with the
+// best events it has when the wrapped backend cannot be reached.
+var ErrOffline = errors.New("calendar backend unreachable")
+
+// QueuedBackend is implemented by backends that hold changes back until
+// they can be sent. Discarded hands over, once, the changes the calendar
+// refused when they were finally sent.
+type QueuedBackend interface {
+	PendingCount() int
+	Discarded() []string
+}
+
+// OfflineBackend wraps another backend and keeps go-home usable without a
+// network. Writes that cannot be sent are put in the journal and replayed,
+// in order, the next time the wrapped backend answers.
+type OfflineBackend struct {
+	inner      CalendarBackend
+	journal    *Journal
+	mu         sync.Mutex
+	offline    bool
+	lastEvents []Event
+	discarded  []string
+}
+
+func NewOfflineBackend(inner CalendarBackend, journal *Journal) *OfflineBackend {
+	return &OfflineBackend{inner: inner, journal: journal}
+}
+
+func isNetworkError(err error) bool {
+	var urlErr *url.Error
+	return errors.As(err, &urlErr)
+}
+
+func (b *OfflineBackend) isOffline() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.offline
+}
+
+func (b *OfflineBackend) setOffline(offline bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.offline = offline
+}
+
+func (b *OfflineBackend) TimeZone() string {
+	if zoned, ok := b.inner.(ZonedBackend); ok {
+		return zoned.TimeZone()
+	}
+	return ""
+}
+
+func (b *OfflineBackend) PendingCount() int {
+	return b.journal.Len()
+}
+
+func (b *OfflineBackend) Discarded() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	discarded := b.discarded
+	b.discarded = nil
+	return discarded
+}
+
+func (b *OfflineBackend) ListEvents(timeMin time.Time, timeMax time.Time) ([]Event, error) {
+	err := b.reconnect()
+	if err == nil {
+		err = b.replay()
+	}
+	if err != nil {
+		if isNetworkError(err) {
+			b.setOffline(true)
+			return b.CachedEvents(timeMin, timeMax), ErrOffline
+		}
+		return nil, err
+	}
+
+	events, err := b.inner.ListEvents(timeMin, timeMax)
+	if err != nil {
+		if isNetworkError(err) {
+			b.setOffline(true)
+			return b.CachedEvents(timeMin, timeMax), ErrOffline
+		}
+		return nil, err
+	}
+	b.mu.Lock()
+	b.lastEvents = events
+	b.mu.Unlock()
+	return b.overlay(events), nil
+}
+
+func (b *OfflineBackend) ListCalendars() ([]Calendar, error) {
+	calendars, err := b.inner.ListCalendars()
+	if err != nil && isNetworkError(err) {
+		b.setOffline(true)
+		return b.CachedCalendars(), nil
+	}
+	return calendars, err
+}
+
+func (b *OfflineBackend) CachedCalendars() []Calendar {
+	if cached, ok := b.inner.(CachedBackend); ok {
+		return cached.CachedCalendars()
+	}
+	return nil
+}
+
+func (b *OfflineBackend) SetCalendarVisible(calendarID string, visible bool) error {
+	return b.inner.SetCalendarVisible(calendarID, visible)
+}
+
+// CachedEvents is what we have locally with the queued changes applied.
+func (b *OfflineBackend) CachedEvents(timeMin time.Time, timeMax time.Time) []Event {
+	var events []Event
+	if cached, ok := b.inner.(CachedBackend); ok {
+		events = cached.CachedEvents(timeMin, timeMax)
+	} else {
+		b.mu.Lock()
+		events = append(events, b.lastEvents...)
+		b.mu.Unlock()
+	}
+	return b.overlay(events)
+}
+
+func (b *OfflineBackend) overlay(events []Event) []Event {
+	entries := b.journal.Snapshot()
+	if len(entries) == 0 {
+		return events
+	}
+	byId := make(map[string]int)
+	result := append([]Event(nil), events...)
+	for i, event := range result {
+		byId[event.Id] = i
+	}
+	deleted := make(map[string]bool)
+	for _, entry := range entries {
+		event := entry.Event
+		event.Pending = true
+		switch entry.Op {
+		case opCreate:
+			byId[event.Id] = len(result)
+			result = append(result, event)
+		case opUpdate, opRespond:
+			if i, ok := byId[event.Id]; ok {
+				result[i] = event
+			}
+		case opMove:
+			if i, ok := byId[event.Id]; ok {
+				result[i].CalendarID = entry.Destination
+			}
+		case opDelete:
+			deleted[event.Id] = true
+		}
+	}
+	var visible []Event
+	for _, event := range result {
+		if !deleted[event.Id] {
+			visible = append(visible, event)
+		}
+	}
+	SortEvents(visible)
+	return visible
+}
+
+// reconnect refreshes credentials once the network is back, the token we
+// started with has usually expired by then.
+func (b *OfflineBackend) reconnect() error {
+	if !b.isOffline() {
+		return nil
+	}
+	err := b.inner.Refresh()
+	if err != nil {
+		return err
+	}
+	b.setOffline(false)
+	return nil
+}
+
+func (b *OfflineBackend) replay() error {
+	for _, entry := range b.journal.Snapshot() {
+		err := b.replayEntry(entry)
+		if err != nil && isUnauthorized(err) {
+			// The token expired while the change waited
+			err = b.inner.Refresh()
+			if err == nil {
+				err = b.replayEntry(entry)
+			}
+		}
+		if err != nil {
+			if !isPermanentError(err) {
+				return err
+			}
+			// The calendar refused the change for good, keeping it would
+			// block everything queued after it
+			log.Printf("Dropping queued %s of %q: %v\n", entry.Op, entry.Event.Summary, err)
+			b.mu.Lock()
+			b.discarded = append(b.discarded, fmt.Sprintf("%s of %q", entry.Op, entry.Event.Summary))
+			b.mu.Unlock()
+		}
+		err = b.journal.PopFront()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *OfflineBackend) replayEntry(entry JournalEntry) error {
+	var err error
+	switch entry.Op {
+	case opCreate:
+		var created Event
+		localId := entry.Event.Id
+		created, err = b.inner.CreateEvent(entry.Event)
+		if err == nil && created.Id != localId {
+			err = b.journal.RenameId(localId, created.Id)
+		}
+	case opUpdate:
+		_, err = b.inner.UpdateEvent(entry.Event)
+	case opRespond:
+		_, err = b.inner.RespondToEvent(entry.Event)
+	case opMove:
+		_, err = b.inner.MoveEvent(entry.Event, entry.Destination)
+	case opDelete:
+		err = b.inner.DeleteEvent(entry.Event)
+	}
+	return err
+}
+
+func (b *OfflineBackend) queue(op string, event Event) (Event, error) {
+	if op == opCreate && event.Id == "" {
+		event.Id = NewLocalId()
+	}
+	// The flag is for showing the event, it is set again when read back
+	event.Pending = false
+	err := b.journal.Append(op, event)
+	if err != nil {
+		return Event{}, err
+	}
+	event.Pending = true
+	return event, nil
+}
+
+// send runs a write directly when online and nothing is waiting ahead of
+// it, otherwise it joins the queue.
+func (b *OfflineBackend) send(op string, event Event, write func() (Event, error)) (Event, error) {
+	if b.isOffline() || b.journal.Len() > 0 || IsLocalId(event.Id) {
+		return b.queue(op, event)
+	}
+	result, err := write()
+	if err != nil && isNetworkError(err) {
+		b.setOffline(true)
+		return b.queue(op, event)
+	}
+	return result, err
+}
+
+func (b *OfflineBackend) CreateEvent(event Event) (Event, error) {
+	return b.send(opCreate, event, func() (Event, error) {
+		return b.inner.CreateEvent(event)
+	})
+}
+
+func (b *OfflineBackend) UpdateEvent(event Event) (Event, error) {
+	return b.send(opUpdate, event, func() (Event, error) {
+		return b.inner.UpdateEvent(event)
+	})
+}
+
+func (b *OfflineBackend) DeleteEvent(event Event) error {
+	_, err := b.send(opDelete, event, func() (Event, error) {
+		return event, b.inner.DeleteEvent(event)
+	})
+	return err
+}
+
+func (b *OfflineBackend) MoveEvent(event Event, destination string) (Event, error) {
+	if b.isOffline() || b.journal.Len() > 0 || IsLocalId(event.Id) {
+		return b.queueMove(event, destination)
+	}
+	moved, err := b.inner.MoveEvent(event, destination)
+	if err != nil && isNetworkError(err) {
+		b.setOffline(true)
+		return b.queueMove(event, destination)
+	}
+	return moved, err
+}
+
+func (b *OfflineBackend) queueMove(event Event, destination string) (Event, error) {
+	event.Pending = false
+	err := b.journal.AppendMove(event, destination)
+	if err != nil {
+		return Event{}, err
+	}
+	event.CalendarID = destination
+	event.Pending = true
+	return event, nil
+}
+
+func (b *OfflineBackend) RespondToEvent(event Event) (Event, error) {
+	return b.send(opRespond, event, func() (Event, error) {
+		return b.inner.RespondToEvent(event)
+	})
+}
+
+func (b *OfflineBackend) GetEvent(calendarID string, eventID string) (Event, error) {
+	return b.inner.GetEvent(calendarID, eventID)
+}
+
+// FreeBusy falls back to the cached events when the network is down.
+func (b *OfflineBackend) FreeBusy(timeMin time.Time, timeMax time.Time) ([]TimeRange, error) {
+	busy, err := b.inner.FreeBusy(timeMin, timeMax)
+	if err != nil && isNetworkError(err) {
+		b.setOffline(true)
+		return BusyFromEvents(b.CachedEvents(timeMin, timeMax)), ErrOffline
+	}
+	return busy, err
+}
+
+// SearchEvents looks through the cached events when the network is down.
+func (b *OfflineBackend) SearchEvents(text string, timeMin time.Time, timeMax time.Time) ([]Event, error) {
+	events, err := b.inner.SearchEvents(text, timeMin, timeMax)
+	if err != nil && isNetworkError(err) {
+		b.setOffline(true)
+		return FilterEvents(b.CachedEvents(timeMin, timeMax), text), ErrOffline
+	}
+	if err != nil {
+		return nil, err
+	}
+	// Google matched the synced events, queued changes are matched here
+	var results []Event
+	for _, event := range b.overlay(events) {
+		if !event.Pending || MatchesSearch(event, text) {
+			results = append(results, event)
+		}
+	}
+	return results, nil
+}
+
+// Refresh treats an unreachable network as going offline rather than
+// as a failure.
+func (b *OfflineBackend) Refresh() error {
+	err := b.inner.Refresh()
+	if err != nil && isNetworkError(err) {
+		b.setOffline(true)
+		return nil
+	}
+	return err
+}
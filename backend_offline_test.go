@@ -0,0 +1,232 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// flakyBackend is a MemoryBackend whose network can be cut and whose token
+// can expire.
+type flakyBackend struct {
+	*MemoryBackend
+	down      bool
+	expired   bool
+	refreshes int
+}
+
+func (b *flakyBackend) unreachable() error {
+	if b.down {
+		return &url.Error{Op: "Post", URL: "https://www.googleapis.com", Err: errors.New("network is down")}
+	}
+	if b.expired {
+		return &StatusError{Request: "POST /calendar/events", Code: http.StatusUnauthorized}
+	}
+	return nil
+}
+
+func (b *flakyBackend) ListEvents(timeMin time.Time, timeMax time.Time) ([]Event, error) {
+	if err := b.unreachable(); err != nil {
+		return nil, err
+	}
+	return b.MemoryBackend.ListEvents(timeMin, timeMax)
+}
+
+func (b *flakyBackend) CreateEvent(event Event) (Event, error) {
+	if err := b.unreachable(); err != nil {
+		return Event{}, err
+	}
+	return b.MemoryBackend.CreateEvent(event)
+}
+
+func (b *flakyBackend) UpdateEvent(event Event) (Event, error) {
+	if err := b.unreachable(); err != nil {
+		return Event{}, err
+	}
+	return b.MemoryBackend.UpdateEvent(event)
+}
+
+func (b *flakyBackend) DeleteEvent(event Event) error {
+	if err := b.unreachable(); err != nil {
+		return err
+	}
+	return b.MemoryBackend.DeleteEvent(event)
+}
+
+func (b *flakyBackend) MoveEvent(event Event, destination string) (Event, error) {
+	if err := b.unreachable(); err != nil {
+		return Event{}, err
+	}
+	return b.MemoryBackend.MoveEvent(event, destination)
+}
+
+func (b *flakyBackend) Refresh() error {
+	if b.down {
+		return b.unreachable()
+	}
+	b.refreshes++
+	b.expired = false
+	return nil
+}
+
+// summaries maps the title of each event to whether it is pending.
+func summaries(events []Event) map[string]bool {
+	found := make(map[string]bool)
+	for _, event := range events {
+		found[event.Summary] = event.Pending
+	}
+	return found
+}
+
+func TestJournalReplay(t *testing.T) {
+	start := time.Date(2026, 1, 5, 9, 0, 0, 0, time.UTC)
+	timeMin, timeMax := start.AddDate(0, 0, -1), start.AddDate(0, 0, 7)
+	at := func(summary string, day int) Event {
+		return Event{
+			Summary: summary,
+			Start:   DateTime{DateTime: start.AddDate(0, 0, day)},
+			End:     DateTime{DateTime: start.AddDate(0, 0, day).Add(time.Hour)},
+		}
+	}
+	inner := &flakyBackend{MemoryBackend: NewMemoryBackend(nil, []Event{at("Standup", 0), at("Review", 1)})}
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	journal, err := LoadJournal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	backend := NewOfflineBackend(inner, journal)
+	loaded, err := backend.ListEvents(timeMin, timeMax)
+	if err != nil {
+		t.Fatal(err)
+	}
+	standup, review := loaded[0], loaded[1]
+
+	inner.down = true
+	if _, err := backend.ListEvents(timeMin, timeMax); !errors.Is(err, ErrOffline) {
+		t.Fatalf("ListEvents while down: %v, want ErrOffline", err)
+	}
+	lunch, err := backend.CreateEvent(at("Lunch", 2))
+	if err != nil || !IsLocalId(lunch.Id) || !lunch.Pending {
+		t.Fatalf("CreateEvent offline = %+v, %v, want a pending local event", lunch, err)
+	}
+	// Changes to an event created offline fold into its create
+	lunch.Summary = "Team lunch"
+	if _, err := backend.UpdateEvent(lunch); err != nil {
+		t.Fatal(err)
+	}
+	standup.Summary = "Standup (moved)"
+	if _, err := backend.UpdateEvent(standup); err != nil {
+		t.Fatal(err)
+	}
+	if err := backend.DeleteEvent(review); err != nil {
+		t.Fatal(err)
+	}
+	if journal.Len() != 3 {
+		t.Fatalf("journal has %d entries, want 3", journal.Len())
+	}
+	// The queue survives a restart
+	if reloaded, err := LoadJournal(); err != nil || reloaded.Len() != 3 {
+		t.Fatalf("journal reloaded with %v, %v", reloaded, err)
+	}
+
+	want := map[string]bool{"Standup (moved)": true, "Team lunch": true}
+	if got := summaries(backend.CachedEvents(timeMin, timeMax)); !reflect.DeepEqual(got, want) {
+		t.Errorf("offline events %v, want %v", got, want)
+	}
+
+	inner.down = false
+	events, err := backend.ListEvents(timeMin, timeMax)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if journal.Len() != 0 {
+		t.Errorf("%d entries left after replay", journal.Len())
+	}
+	want = map[string]bool{"Standup (moved)": false, "Team lunch": false}
+	got := summaries(events)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("events after replay %v, want %v", got, want)
+	}
+	for _, event := range events {
+		if IsLocalId(event.Id) {
+			t.Errorf("%q kept its local id %s", event.Summary, event.Id)
+		}
+	}
+}
+
+func TestJournalReplayRefused(t *testing.T) {
+	start := time.Date(2026, 1, 5, 9, 0, 0, 0, time.UTC)
+	timeMin, timeMax := start.AddDate(0, 0, -1), start.AddDate(0, 0, 7)
+	at := func(summary string, day int) Event {
+		return Event{
+			Summary:    summary,
+			CalendarID: "home",
+			Start:      DateTime{DateTime: start.AddDate(0, 0, day)},
+			End:        DateTime{DateTime: start.AddDate(0, 0, day).Add(time.Hour)},
+		}
+	}
+	calendars := []Calendar{{Id: "home", Visible: true}, {Id: "work", Visible: true}}
+	inner := &flakyBackend{MemoryBackend: NewMemoryBackend(calendars, []Event{at("Standup", 0), at("Review", 1)})}
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	journal, err := LoadJournal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	backend := NewOfflineBackend(inner, journal)
+	loaded, err := backend.ListEvents(timeMin, timeMax)
+	if err != nil {
+		t.Fatal(err)
+	}
+	standup, review := loaded[0], loaded[1]
+
+	inner.down = true
+	moved, err := backend.MoveEvent(standup, "work")
+	if err != nil || moved.CalendarID != "work" || !moved.Pending {
+		t.Fatalf("MoveEvent offline = %+v, %v, want a pending event on work", moved, err)
+	}
+	review.Summary = "Review (renamed)"
+	if _, err := backend.UpdateEvent(review); err != nil {
+		t.Fatal(err)
+	}
+	for _, event := range backend.CachedEvents(timeMin, timeMax) {
+		if event.Summary == "Standup" && event.CalendarID != "work" {
+			t.Errorf("queued move shows Standup on %q, want work", event.CalendarID)
+		}
+	}
+	// Someone else deletes the event that was edited offline
+	if err := inner.MemoryBackend.DeleteEvent(review); err != nil {
+		t.Fatal(err)
+	}
+
+	// go-home starts again once the network is back, with a stale token
+	inner.down = false
+	inner.expired = true
+	journal, err = LoadJournal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	backend = NewOfflineBackend(inner, journal)
+	events, err := backend.ListEvents(timeMin, timeMax)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inner.refreshes != 1 {
+		t.Errorf("token refreshed %d times, want 1", inner.refreshes)
+	}
+	if journal.Len() != 0 {
+		t.Errorf("%d entries left after replay", journal.Len())
+	}
+	if len(events) != 1 || events[0].CalendarID != "work" || events[0].Pending {
+		t.Errorf("events after replay %+v, want Standup synced to work", events)
+	}
+	want := []string{`update of "Review (renamed)"`}
+	if got := backend.Discarded(); !reflect.DeepEqual(got, want) {
+		t.Errorf("discarded %q, want %q", got, want)
+	}
+	if got := backend.Discarded(); got != nil {
+		t.Errorf("discarded reported again: %q", got)
+	}
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// What a batch action does to the marked events.
+const (
+	batchDelete = iota
+	batchShift
+	batchCalendar
+	batchCopy
+)
+
+var batchLabels = []string{"Delete", "Shift by a time", "Move to another calendar", "Copy to another day"}
+
+// BatchAction is an action picked for the marked events, with its
+// argument.
+type BatchAction struct {
+	Kind       int
+	Shift      time.Duration
+	Day        time.Time
+	CalendarID string
+}
+
+// BatchResult is how the action went for one of the marked events.
+type BatchResult struct {
+	Event Event
+	Err   error
+}
+
+type batchDoneMsg struct {
+	label   string
+	results []BatchResult
+	changes []Change
+}
+
+// ParseShift reads how far to move events, like "30m", "-1d" or "+1h30m".
+func ParseShift(value string) (time.Duration, error) {
+	text := strings.TrimSpace(value)
+	sign := time.Duration(1)
+	if rest, ok := strings.CutPrefix(text, "-"); ok {
+		sign, text = -1, rest
+	} else {
+		text = strings.TrimPrefix(text, "+")
+	}
+	tokens := strings.Fields(text)
+	if d, used, ok := parseDuration(tokens); ok && used == len(tokens) {
+		return sign * d, nil
+	}
+	return 0, fmt.Errorf("%q is not a time like 30m, -1d or 2h", value)
+}
+
+// ShiftEvent moves event by d. Whole days move by calendar day, so the time
+// of day stays the same across daylight saving changes.
+func ShiftEvent(event Event, d time.Duration) (Event, error) {
+	loc := CalendarLocation()
+	if d%(24*time.Hour) == 0 {
+		days := int(d / (24 * time.Hour))
+		event.Start.DateTime = event.Start.DateTime.In(loc).AddDate(0, 0, days)
+		event.End.DateTime = event.End.DateTime.In(loc).AddDate(0, 0, days)
+	} else if event.AllDay {
+		return event, fmt.Errorf("all-day events move by whole days")
+	} else {
+		event.Start.DateTime = event.Start.DateTime.Add(d)
+		event.End.DateTime = event.End.DateTime.Add(d)
+	}
+	event.Start.Date = event.Start.DateTime.In(loc).Format(time.DateOnly)
+	event.End.Date = event.End.DateTime.In(loc).Format(time.DateOnly)
+	return event, nil
+}
+
+// CopyToDay is a new event like event on day, at the same time of day. A
+// copy of an occurrence does not repeat, and gets its own video call.
+func CopyToDay(event Event, day time.Time) Event {
+	loc := CalendarLocation()
+	days := DaysBetween(StartOfDay(event.Start.DateTime.In(loc)), day)
+	event, _ = ShiftEvent(event, time.Duration(days)*24*time.Hour)
+	event.Id = ""
+	event.Etag = ""
+	event.Pending = false
+	event.RecurringEventId = ""
+	event.Recurrence = nil
+	event.AddConference = ConferenceLink(event) != ""
+	event.Conference = Conference{}
+	return event
+}
+
+// Label describes the action done to count events, like "shifted 3 events
+// 30m later", for the summary and the undo status line.
+func (a BatchAction) Label(count int, calendars []Calendar) string {
+	return a.describe(count, calendars, []string{"deleted", "shifted", "moved", "copied"})
+}
+
+// Question asks to do the action to count events, for the confirmation.
+func (a BatchAction) Question(count int, calendars []Calendar) string {
+	return a.describe(count, calendars, []string{"Delete", "Shift", "Move", "Copy"}) + "?"
+}
+
+func (a BatchAction) describe(count int, calendars []Calendar, verbs []string) string {
+	events := fmt.Sprintf("%d events", count)
+	if count == 1 {
+		events = "1 event"
+	}
+	switch a.Kind {
+	case batchShift:
+		direction := "later"
+		shift := a.Shift
+		if shift < 0 {
+			direction, shift = "earlier", -shift
+		}
+		return fmt.Sprintf("%s %s %s %s", verbs[batchShift], events, FormatDuration(shift), direction)
+	case batchCalendar:
+		name := a.CalendarID
+		if cal, ok := FindCalendar(calendars, a.CalendarID); ok {
+			name = cal.Summary
+		}
+		return fmt.Sprintf("%s %s to %s", verbs[batchCalendar], events, name)
+	case batchCopy:
+		return fmt.Sprintf("%s %s to %s", verbs[batchCopy], events, a.Day.Format("Mon 2 Jan"))
+	}
+	return verbs[batchDelete] + " " + events
+}
+
+// Preview is what the action will do to event, for the confirmation.
+func (a BatchAction) Preview(event Event, calendars []Calendar) string {
+	switch a.Kind {
+	case batchShift:
+		shifted, err := ShiftEvent(event, a.Shift)
+		if err != nil {
+			return err.Error()
+		}
+		return "→ " + EventWhen(shifted)
+	case batchCalendar:
+		if cal, ok := FindCalendar(calendars, a.CalendarID); ok {
+			return "→ " + cal.Summary
+		}
+		return "→ " + a.CalendarID
+	case batchCopy:
+		return "+ " + EventWhen(CopyToDay(event, a.Day))
+	}
+	return "✗ delete"
+}
+
+// batchCmd runs action on each event in turn. A failure does not stop the
+// others, every event gets its own result.
+func batchCmd(backend CalendarBackend, action BatchAction, events []Event, label string) tea.Cmd {
+	return func() tea.Msg {
+		var results []BatchResult
+		var changes []Change
+		for _, event := range events {
+			var err error
+			switch action.Kind {
+			case batchDelete:
+				err = backend.DeleteEvent(event)
+				if err == nil {
+					changes = append(changes, Change{Op: opDelete, Before: event})
+				}
+			case batchShift:
+				var shifted Event
+				shifted, err = ShiftEvent(event, action.Shift)
+				if err == nil {
+					shifted, err = backend.UpdateEvent(shifted)
+				}
+				if err == nil {
+					changes = append(changes, Change{Op: opUpdate, Before: event, After: shifted})
+				}
+			case batchCalendar:
+				if event.CalendarID == action.CalendarID {
+					break
+				}
+				var moved Event
+				moved, err = backend.MoveEvent(event, action.CalendarID)
+				if err == nil {
+					changes = append(changes, Change{Op: opMove, Before: event, After: moved})
+				}
+			case batchCopy:
+				var copied Event
+				copied, err = backend.CreateEvent(CopyToDay(event, action.Day))
+				if err == nil {
+					changes = append(changes, Change{Op: opCreate, After: copied})
+				}
+			}
+			results = append(results, BatchResult{Event: event, Err: err})
+		}
+		return batchDoneMsg{label: label, results: results, changes: changes}
+	}
+}
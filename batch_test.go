@@ -0,0 +1,68 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseShift(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Duration
+		ok    bool
+	}{
+		{"30m", 30 * time.Minute, true},
+		{"-1d", -24 * time.Hour, true},
+		{"+1h30m", 90 * time.Minute, true},
+		{" 2 days ", 48 * time.Hour, true},
+		{"1.5h", 90 * time.Minute, true},
+		{"-45 min", -45 * time.Minute, true},
+		{"", 0, false},
+		{"later", 0, false},
+		{"0m", 0, false},
+		{"1h and 2m", 0, false},
+	}
+	for _, tt := range tests {
+		got, err := ParseShift(tt.value)
+		if (err == nil) != tt.ok {
+			t.Errorf("ParseShift(%q) error %v, want ok %v", tt.value, err, tt.ok)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseShift(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestShiftEvent(t *testing.T) {
+	start := time.Date(2026, 1, 5, 9, 0, 0, 0, time.UTC)
+	timed := Event{Start: DateTime{DateTime: start}, End: DateTime{DateTime: start.Add(time.Hour)}}
+	allDay := Event{AllDay: true, Start: DateTime{DateTime: StartOfDay(start)}, End: DateTime{DateTime: StartOfDay(start).AddDate(0, 0, 1)}}
+	tests := []struct {
+		event Event
+		shift time.Duration
+		start time.Time
+		ok    bool
+	}{
+		{timed, 30 * time.Minute, start.Add(30 * time.Minute), true},
+		{timed, -24 * time.Hour, start.AddDate(0, 0, -1), true},
+		{allDay, 48 * time.Hour, StartOfDay(start).AddDate(0, 0, 2), true},
+		{allDay, time.Hour, time.Time{}, false},
+	}
+	for _, tt := range tests {
+		shifted, err := ShiftEvent(tt.event, tt.shift)
+		if (err == nil) != tt.ok {
+			t.Errorf("ShiftEvent(all day %v, %v) error %v, want ok %v", tt.event.AllDay, tt.shift, err, tt.ok)
+			continue
+		}
+		if !tt.ok {
+			continue
+		}
+		if !shifted.Start.DateTime.Equal(tt.start) {
+			t.Errorf("ShiftEvent(all day %v, %v) starts %v, want %v", tt.event.AllDay, tt.shift, shifted.Start.DateTime, tt.start)
+		}
+		if got := shifted.End.DateTime.Sub(shifted.Start.DateTime); got != tt.event.End.DateTime.Sub(tt.event.Start.DateTime) {
+			t.Errorf("ShiftEvent(all day %v, %v) changed the length to %v", tt.event.AllDay, tt.shift, got)
+		}
+	}
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// syncPadding widens a full sync past the visible window so the sync token
+// stays usable as the days roll forward.
+const syncPadding = 14 * 24 * time.Hour
+
+// cacheVersion is bumped whenever Event gains fields, since incremental
+// syncs would never fill them in for events that did not change.
+const cacheVersion = 5
+
+// EventCache is the on-disk copy of the calendars used to paint the TUI
+// before the network answers and to ask Google only for deltas.
+type EventCache struct {
+	mu           sync.Mutex
+	path         string
+	Version      int                      `json:"version"`
+	CalendarList []Calendar               `json:"calendarList"`
+	Calendars    map[string]*CalendarSync `json:"calendars"`
+}
+
+// CalendarSync is the sync state and events of a single calendar.
+type CalendarSync struct {
+	SyncToken string           `json:"syncToken"`
+	TimeMin   time.Time        `json:"timeMin"`
+	TimeMax   time.Time        `json:"timeMax"`
+	TimeZone  string           `json:"timeZone"`
+	Events    map[string]Event `json:"events"`
+}
+
+// CachePath is where the cache called name is kept. The TUI and the
+// notifier each keep their own, so neither overwrites the other's sync.
+func CachePath(name string) (string, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "go-home", name), nil
+}
+
+// LoadEventCache reads the cache from disk. A missing file is not an error,
+// it just means the next sync will be a full one.
+func LoadEventCache(name string) (*EventCache, error) {
+	path, err := CachePath(name)
+	if err != nil {
+		return nil, err
+	}
+	cache := &EventCache{path: path, Version: cacheVersion, Calendars: make(map[string]*CalendarSync)}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cache, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(data, cache)
+	if err != nil || cache.Version != cacheVersion {
+		// A corrupt or outdated cache only costs a full sync
+		return &EventCache{path: path, Version: cacheVersion, Calendars: make(map[string]*CalendarSync)}, nil
+	}
+	if cache.Calendars == nil {
+		cache.Calendars = make(map[string]*CalendarSync)
+	}
+	return cache, nil
+}
+
+func (c *EventCache) Save() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	data, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(c.path), 0755)
+	if err != nil {
+		return err
+	}
+	// A file of our own, so a CLI command saving at the same time cannot
+	// write into it
+	tmp, err := os.CreateTemp(filepath.Dir(c.path), filepath.Base(c.path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), 0644)
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), c.path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+	return err
+}
+
+// calendar returns the sync state for calendarID, creating it on first use.
+// Callers must hold c.mu.
+func (c *EventCache) calendar(calendarID string) *CalendarSync {
+	cal, ok := c.Calendars[calendarID]
+	if !ok || cal.Events == nil {
+		cal = &CalendarSync{Events: make(map[string]Event)}
+		c.Calendars[calendarID] = cal
+	}
+	return cal
+}
+
+// SyncTokenFor returns the stored sync token if the cached window covers
+// the requested one, otherwise an empty string.
+func (c *EventCache) SyncTokenFor(calendarID string, timeMin time.Time, timeMax time.Time) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cal := c.calendar(calendarID)
+	if cal.TimeMin.After(timeMin) || cal.TimeMax.Before(timeMax) {
+		return ""
+	}
+	return cal.SyncToken
+}
+
+func (c *EventCache) SetSyncToken(calendarID string, token string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.calendar(calendarID).SyncToken = token
+}
+
+func (c *EventCache) SetTimeZone(calendarID string, timeZone string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if timeZone != "" {
+		c.calendar(calendarID).TimeZone = timeZone
+	}
+}
+
+func (c *EventCache) TimeZone(calendarID string) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.calendar(calendarID).TimeZone
+}
+
+func (c *EventCache) SetWindow(calendarID string, timeMin time.Time, timeMax time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	cal := c.calendar(calendarID)
+	cal.TimeMin = timeMin
+	cal.TimeMax = timeMax
+}
+
+func (c *EventCache) Reset(calendarID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	cal := c.calendar(calendarID)
+	cal.SyncToken = ""
+	cal.Events = make(map[string]Event)
+}
+
+// Apply merges changed events and drops removed ids. Events whose etag has
+// not moved are left untouched.
+func (c *EventCache) Apply(calendarID string, changed []Event, removed []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cal := c.calendar(calendarID)
+	for _, event := range changed {
+		cached, ok := cal.Events[event.Id]
+		if ok && event.Etag != "" && cached.Etag == event.Etag {
+			continue
+		}
+		cal.Events[event.Id] = event
+	}
+	for _, id := range removed {
+		delete(cal.Events, id)
+	}
+}
+
+func (c *EventCache) SetCalendarList(calendars []Calendar) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.CalendarList = calendars
+}
+
+func (c *EventCache) CalendarListCopy() []Calendar {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]Calendar(nil), c.CalendarList...)
+}
+
+func (c *EventCache) EventsBetween(calendarIDs []string, timeMin time.Time, timeMax time.Time) []Event {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var events []Event
+	for _, calendarID := range calendarIDs {
+		for _, event := range c.calendar(calendarID).Events {
+			if event.End.DateTime.After(timeMin) && event.Start.DateTime.Before(timeMax) {
+				events = append(events, event)
+			}
+		}
+	}
+	SortEvents(events)
+	return events
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/joho/godotenv"
+)
+
+type Calendar struct {
+	Id         string `json:"id"`
+	Summary    string `json:"summary"`
+	Color      string `json:"backgroundColor"`
+	AccessRole string `json:"accessRole"`
+	Primary    bool   `json:"primary"`
+	Default    bool   `json:"default"`
+	Visible    bool   `json:"visible"`
+	// DefaultReminders apply to events that do not set their own
+	DefaultReminders []Reminder `json:"defaultReminders"`
+}
+
+type CalendarListResponse struct {
+	NextPageToken string `json:"nextPageToken"`
+	Items         []struct {
+		ID               string     `json:"id"`
+		Summary          string     `json:"summary"`
+		SummaryOverride  string     `json:"summaryOverride"`
+		BackgroundColor  string     `json:"backgroundColor"`
+		AccessRole       string     `json:"accessRole"`
+		Primary          bool       `json:"primary"`
+		DefaultReminders []Reminder `json:"defaultReminders"`
+	} `json:"items"`
+}
+
+func GetCalendarList(config apiConfig) ([]Calendar, error) {
+	var calendars []Calendar
+	q := url.Values{}
+	for {
+		req, err := http.NewRequest("GET", "https://www.googleapis.com/calendar/v3/users/me/calendarList", nil)
+		if err != nil {
+			log.Printf("GET /calendarList Error creating new req %v\n", err)
+			return nil, err
+		}
+		req.Header.Set("Authorization", config.accessToken)
+		req.URL.RawQuery = q.Encode()
+
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			log.Printf("GET /calendarList Error fetching data %v\n", err)
+			return nil, err
+		}
+		body, err := io.ReadAll(res.Body)
+		res.Body.Close()
+		if err != nil {
+			log.Printf("GET /calendarList Error reading body %v\n", err)
+			return nil, err
+		}
+		if res.StatusCode != http.StatusOK {
+			log.Printf("GET /calendarList Error failed with status code %v\n with body %v\n", res.StatusCode, string(body))
+			return nil, fmt.Errorf("GET /calendarList failed with status code %d", res.StatusCode)
+		}
+
+		var list CalendarListResponse
+		err = json.Unmarshal(body, &list)
+		if err != nil {
+			log.Printf("GET /calendarList Error unmarshaling body %v\n", err)
+			return nil, err
+		}
+		for _, item := range list.Items {
+			summary := item.Summary
+			if item.SummaryOverride != "" {
+				summary = item.SummaryOverride
+			}
+			calendars = append(calendars, Calendar{
+				Id:         item.ID,
+				Summary:    summary,
+				Color:      item.BackgroundColor,
+				AccessRole: item.AccessRole,
+				Primary:    item.Primary,
+				Default:    item.ID == config.calendarID,
+				Visible:    config.IsVisible(item.ID),
+
+				DefaultReminders: item.DefaultReminders,
+			})
+		}
+		if list.NextPageToken == "" {
+			break
+		}
+		q.Set("pageToken", list.NextPageToken)
+	}
+	return calendars, nil
+}
+
+// IsVisible reports whether a calendar's events are shown in the grid.
+func (config apiConfig) IsVisible(calendarID string) bool {
+	for _, id := range config.visibleCalendars {
+		if id == calendarID {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseVisibleCalendars reads VISIBLE_CALENDARS, falling back to just the
+// default calendar.
+func ParseVisibleCalendars(value string, defaultID string) []string {
+	var ids []string
+	for _, id := range strings.Split(value, ",") {
+		id = strings.TrimSpace(id)
+		if id != "" {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		ids = []string{defaultID}
+	}
+	return ids
+}
+
+// SaveVisibleCalendars writes the visible calendars back to the .env so the
+// choice survives a restart.
+func SaveVisibleCalendars(ids []string) error {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(configDir, "go-home", ".env")
+	envMap, err := godotenv.Read(path)
+	if err != nil {
+		return err
+	}
+	envMap["VISIBLE_CALENDARS"] = strings.Join(ids, ",")
+	return godotenv.Write(envMap, path)
+}
+
+// FindCalendar matches a calendar by id or by its name, ignoring case.
+func FindCalendar(calendars []Calendar, value string) (Calendar, bool) {
+	for _, calendar := range calendars {
+		if calendar.Id == value || strings.EqualFold(calendar.Summary, value) {
+			return calendar, true
+		}
+	}
+	return Calendar{}, false
+}
+
+func DefaultCalendar(calendars []Calendar) (Calendar, bool) {
+	for _, calendar := range calendars {
+		if calendar.Default {
+			return calendar, true
+		}
+	}
+	return Calendar{}, false
+}
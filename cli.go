@@ -0,0 +1,606 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// Exit codes of the scripting subcommands. Scripts depend on them, so
+// existing codes must never be renumbered.
+const (
+	exitOK       = 0
+	exitError    = 1 // the calendar could not be read or written
+	exitUsage    = 2 // bad flags or arguments
+	exitNotFound = 3 // no event with that id, or nothing coming up for next
+)
+
+var cliCommands = map[string]func(args []string) int{
+	"list":   cliList,
+	"next":   cliNext,
+	"add":    cliAdd,
+	"edit":   cliEdit,
+	"delete": cliDelete,
+}
+
+// IsCLICommand reports whether go-home was started as a scripting
+// subcommand rather than the TUI.
+func IsCLICommand(name string) bool {
+	_, ok := cliCommands[name]
+	return ok
+}
+
+// RunCLI runs a scripting subcommand and returns its exit code.
+func RunCLI(name string, args []string) int {
+	return cliCommands[name](args)
+}
+
+// CLIEvent is the JSON printed for an event. It is kept apart from Event
+// so the output stays the same when Event changes.
+type CLIEvent struct {
+	Id               string     `json:"id"`
+	CalendarID       string     `json:"calendarId"`
+	Calendar         string     `json:"calendar,omitempty"`
+	Summary          string     `json:"summary"`
+	Start            time.Time  `json:"start"`
+	End              time.Time  `json:"end"`
+	AllDay           bool       `json:"allDay"`
+	Location         string     `json:"location,omitempty"`
+	Description      string     `json:"description,omitempty"`
+	Recurrence       []string   `json:"recurrence,omitempty"`
+	RecurringEventId string     `json:"recurringEventId,omitempty"`
+	Response         string     `json:"response,omitempty"`
+	Guests           []CLIGuest `json:"guests,omitempty"`
+	ConferenceLink   string     `json:"conferenceLink,omitempty"`
+	HTMLLink         string     `json:"htmlLink,omitempty"`
+	Reminders        []Reminder `json:"reminders,omitempty"`
+	Pending          bool       `json:"pending,omitempty"`
+}
+
+type CLIGuest struct {
+	Email    string `json:"email"`
+	Response string `json:"response"`
+	Optional bool   `json:"optional,omitempty"`
+}
+
+func NewCLIEvent(event Event, calendars []Calendar) CLIEvent {
+	loc := CalendarLocation()
+	out := CLIEvent{
+		Id:               event.Id,
+		CalendarID:       event.CalendarID,
+		Summary:          event.Summary,
+		Start:            event.Start.DateTime.In(loc),
+		End:              event.End.DateTime.In(loc),
+		AllDay:           event.AllDay,
+		Location:         event.Location,
+		Description:      PlainDescription(event.Description),
+		Recurrence:       event.Recurrence,
+		RecurringEventId: event.RecurringEventId,
+		Response:         SelfResponse(event),
+		ConferenceLink:   ConferenceLink(event),
+		HTMLLink:         event.HTMLLink,
+		Pending:          event.Pending,
+	}
+	if cal, ok := FindCalendar(calendars, event.CalendarID); ok {
+		out.Calendar = cal.Summary
+		if !event.CustomReminders {
+			out.Reminders = cal.DefaultReminders
+		}
+	}
+	if event.CustomReminders {
+		out.Reminders = event.Reminders
+	}
+	for _, attendee := range event.Attendees {
+		if !attendee.Self {
+			out.Guests = append(out.Guests, CLIGuest{Email: attendee.Email, Response: attendee.ResponseStatus, Optional: attendee.Optional})
+		}
+	}
+	return out
+}
+
+// cliSession is what every subcommand needs: the parsed common flags and,
+// once connected, the backend.
+type cliSession struct {
+	fs      *flag.FlagSet
+	demo    *bool
+	json    *bool
+	backend CalendarBackend
+}
+
+func newCLISession(name string, usage string) *cliSession {
+	s := &cliSession{fs: flag.NewFlagSet(name, flag.ContinueOnError)}
+	s.demo = s.fs.Bool("d", false, "Use the in-memory demo calendar")
+	s.json = s.fs.Bool("json", false, "Print JSON instead of text")
+	s.fs.Usage = func() {
+		fmt.Fprintln(s.fs.Output(), "Usage: go-home "+name+" "+usage)
+		s.fs.PrintDefaults()
+	}
+	return s
+}
+
+// parse reads flags wherever they are, so "edit <id> --title x" works as
+// well as "edit --title x <id>", and returns the other arguments. code is
+// the exit code to stop with when ok is false.
+func (s *cliSession) parse(args []string) (positional []string, code int, ok bool) {
+	for {
+		err := s.fs.Parse(args)
+		if errors.Is(err, flag.ErrHelp) {
+			return nil, exitOK, false
+		}
+		if err != nil {
+			return nil, exitUsage, false
+		}
+		args = s.fs.Args()
+		if len(args) == 0 {
+			return positional, exitOK, true
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// connect loads the config and opens the calendar. Log output goes to the
+// log file so stdout and stderr stay clean for scripts.
+func (s *cliSession) connect() {
+	LoadConfig(*s.demo)
+	LogToConfigDir()
+	if *s.demo {
+		s.backend = NewMemoryBackend(DemoCalendars(), DemoEvents())
+	} else {
+		s.backend = NewCalendarBackend()
+	}
+	if zoned, ok := s.backend.(ZonedBackend); ok && !configuredTimeZone && zoned.TimeZone() != "" {
+		SetCalendarLocation(zoned.TimeZone())
+	}
+}
+
+// calendars is the calendar list, falling back to the cached one offline.
+func (s *cliSession) calendars() []Calendar {
+	calendars, err := s.backend.ListCalendars()
+	if err != nil {
+		if cached, ok := s.backend.(CachedBackend); ok {
+			return cached.CachedCalendars()
+		}
+	}
+	return calendars
+}
+
+func (s *cliSession) fail(code int, format string, args ...any) int {
+	fmt.Fprintf(os.Stderr, "go-home: "+format+"\n", args...)
+	return code
+}
+
+func (s *cliSession) printEvents(w io.Writer, events []Event, calendars []Calendar) {
+	if *s.json {
+		out := []CLIEvent{}
+		for _, event := range events {
+			out = append(out, NewCLIEvent(event, calendars))
+		}
+		printJSON(w, out)
+		return
+	}
+	for _, event := range events {
+		fmt.Fprintln(w, eventLine(event))
+	}
+}
+
+func (s *cliSession) printEvent(w io.Writer, event Event, calendars []Calendar) {
+	if *s.json {
+		printJSON(w, NewCLIEvent(event, calendars))
+		return
+	}
+	fmt.Fprintln(w, eventLine(event))
+}
+
+func printJSON(w io.Writer, v any) {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(v)
+}
+
+// eventLine is the text form of an event: id, when, summary and where,
+// separated by tabs so it can be cut.
+func eventLine(event Event) string {
+	line := event.Id + "\t" + EventWhen(event) + "\t" + event.Summary
+	if event.Location != "" {
+		line += "\t@ " + event.Location
+	}
+	if event.Pending {
+		line += "\t(pending)"
+	}
+	return line
+}
+
+// listEvents is the events from the start of today through days ahead.
+// Offline it warns and uses the cached events.
+func (s *cliSession) listEvents(days int) ([]Event, error) {
+	today := StartOfDay(Now())
+	events, err := s.backend.ListEvents(today, today.AddDate(0, 0, days))
+	if errors.Is(err, ErrOffline) {
+		fmt.Fprintln(os.Stderr, "go-home: offline, showing cached events")
+		err = nil
+	}
+	return VisibleEvents(events), err
+}
+
+func cliList(args []string) int {
+	s := newCLISession("list", "[--days N] [--json]")
+	days := s.fs.Int("days", 7, "How many days to list, starting today")
+	positional, code, ok := s.parse(args)
+	if !ok {
+		return code
+	}
+	if len(positional) > 0 || *days < 1 {
+		s.fs.Usage()
+		return exitUsage
+	}
+	s.connect()
+	events, err := s.listEvents(*days)
+	if err != nil {
+		return s.fail(exitError, "failed to list events: %v", err)
+	}
+	s.printEvents(os.Stdout, events, s.calendars())
+	return exitOK
+}
+
+// cliNext prints the next event to start, skipping all-day events.
+func cliNext(args []string) int {
+	s := newCLISession("next", "[--days N] [--json]")
+	days := s.fs.Int("days", 7, "How far ahead to look")
+	positional, code, ok := s.parse(args)
+	if !ok {
+		return code
+	}
+	if len(positional) > 0 || *days < 1 {
+		s.fs.Usage()
+		return exitUsage
+	}
+	s.connect()
+	events, err := s.listEvents(*days)
+	if err != nil {
+		return s.fail(exitError, "failed to list events: %v", err)
+	}
+	now := Now()
+	for _, event := range events {
+		if !event.AllDay && event.Start.DateTime.After(now) {
+			s.printEvent(os.Stdout, event, s.calendars())
+			return exitOK
+		}
+	}
+	return s.fail(exitNotFound, "no events in the next %d days", *days)
+}
+
+// eventFlags are the flags add and edit share to set an event's fields.
+type eventFlags struct {
+	fs          *flag.FlagSet
+	title       *string
+	date        *string
+	endDate     *string
+	start       *string
+	end         *string
+	duration    *time.Duration
+	allDay      *bool
+	location    *string
+	description *string
+	calendar    *string
+	repeat      *string
+	guests      *string
+	video       *bool
+	notify      *string
+	reminders   *string
+}
+
+func addEventFlags(fs *flag.FlagSet) *eventFlags {
+	return &eventFlags{
+		fs:          fs,
+		title:       fs.String("title", "", "Event title"),
+		date:        fs.String("date", "", "Day the event starts: YYYY-MM-DD, today, tomorrow, fri, next mon"),
+		endDate:     fs.String("end-date", "", "Last day of an event spanning several days"),
+		start:       fs.String("start", "", "Start time, like 9:30 or 2pm"),
+		end:         fs.String("end", "", "End time, like 10:00 or 3pm"),
+		duration:    fs.Duration("duration", 0, "Length instead of an end time, like 45m or 2h"),
+		allDay:      fs.Bool("all-day", false, "Make it an all-day event"),
+		location:    fs.String("location", "", "Where the event is"),
+		description: fs.String("description", "", "Event description"),
+		calendar:    fs.String("calendar", "", "Calendar name or id, the default calendar when empty"),
+		repeat:      fs.String("repeat", "", "Recurrence like \"weekly on mon,wed\", or none"),
+		guests:      fs.String("guests", "", "Comma separated guest emails, replacing the guest list"),
+		video:       fs.Bool("video", false, "Add a Google Meet link"),
+		notify:      fs.String("notify", "all", "Who to email about the change: all, external or none"),
+		reminders:   fs.String("reminders", "", "default, none or a list like \"popup 10m, email 1d\""),
+	}
+}
+
+func (f *eventFlags) set() map[string]bool {
+	set := make(map[string]bool)
+	f.fs.Visit(func(fl *flag.Flag) { set[fl.Name] = true })
+	return set
+}
+
+func parseClockFlag(value string) (int, error) {
+	c, ok := parseClock(strings.TrimSpace(value))
+	if !ok {
+		return 0, fmt.Errorf("%q is not a time like 9:30 or 2pm", value)
+	}
+	return c.minutes(""), nil
+}
+
+// apply sets the fields given on the command line on event. Times that are
+// not given keep their value, moving the start keeps the event's length.
+func (f *eventFlags) apply(event Event, calendars []Calendar) (Event, error) {
+	set := f.set()
+	loc := CalendarLocation()
+	start := event.Start.DateTime.In(loc)
+	end := event.End.DateTime.In(loc)
+	length := end.Sub(start)
+
+	if set["title"] {
+		event.Summary = *f.title
+	}
+	if strings.TrimSpace(event.Summary) == "" {
+		return Event{}, fmt.Errorf("the event needs a title")
+	}
+	if set["location"] {
+		event.Location = *f.location
+	}
+	if set["description"] {
+		event.Description = *f.description
+	}
+	if set["all-day"] && event.AllDay != *f.allDay {
+		event.AllDay = *f.allDay
+		if event.AllDay {
+			length = 24 * time.Hour
+		} else {
+			length = defaultDuration
+		}
+	}
+
+	day := StartOfDay(start)
+	if set["date"] {
+		d, err := ParseDay(*f.date)
+		if err != nil {
+			return Event{}, err
+		}
+		day = d
+	}
+	if event.AllDay {
+		if set["start"] || set["end"] {
+			return Event{}, fmt.Errorf("all-day events cannot have a time")
+		}
+		days := max(1, int((length+12*time.Hour)/(24*time.Hour)))
+		if set["duration"] {
+			days = max(1, int(*f.duration/(24*time.Hour)))
+		}
+		event.Start.DateTime = day
+		event.End.DateTime = day.AddDate(0, 0, days)
+		if set["end-date"] {
+			last, err := ParseDay(*f.endDate)
+			if err != nil {
+				return Event{}, err
+			}
+			// The API treats the end date of all-day events as exclusive
+			event.End.DateTime = last.AddDate(0, 0, 1)
+		}
+	} else {
+		startMinutes := start.Hour()*60 + start.Minute()
+		if set["start"] {
+			minutes, err := parseClockFlag(*f.start)
+			if err != nil {
+				return Event{}, err
+			}
+			startMinutes = minutes
+		}
+		event.Start.DateTime = atMinutes(day, startMinutes)
+		switch {
+		case set["end"]:
+			endDay := day
+			if set["end-date"] {
+				d, err := ParseDay(*f.endDate)
+				if err != nil {
+					return Event{}, err
+				}
+				endDay = d
+			}
+			minutes, err := parseClockFlag(*f.end)
+			if err != nil {
+				return Event{}, err
+			}
+			event.End.DateTime = atMinutes(endDay, minutes)
+		case set["duration"]:
+			event.End.DateTime = event.Start.DateTime.Add(*f.duration)
+		default:
+			event.End.DateTime = event.Start.DateTime.Add(length)
+		}
+	}
+	if !event.End.DateTime.After(event.Start.DateTime) {
+		return Event{}, fmt.Errorf("the event ends before it starts")
+	}
+
+	if set["calendar"] {
+		cal, ok := FindCalendar(calendars, *f.calendar)
+		if !ok {
+			return Event{}, fmt.Errorf("no calendar named %q", *f.calendar)
+		}
+		if event.Id != "" && cal.Id != event.CalendarID {
+			return Event{}, fmt.Errorf("moving events between calendars is not supported")
+		}
+		event.CalendarID = cal.Id
+	}
+	if set["repeat"] {
+		recurrence, err := ParseRepeat(*f.repeat, event.AllDay)
+		if err != nil {
+			return Event{}, err
+		}
+		event.Recurrence = recurrence
+	}
+	if set["guests"] {
+		emails, err := ParseGuests(*f.guests)
+		if err != nil {
+			return Event{}, err
+		}
+		event.Attendees = MergeAttendees(event.Attendees, emails)
+	}
+	event.AddConference = *f.video && ConferenceLink(event) == ""
+	sendUpdates, err := ParseSendUpdates(*f.notify)
+	if err != nil {
+		return Event{}, err
+	}
+	event.SendUpdates = sendUpdates
+	if set["reminders"] {
+		event.Reminders, event.CustomReminders, err = ParseReminders(*f.reminders)
+		if err != nil {
+			return Event{}, err
+		}
+	}
+	return normalizeEvent(event), nil
+}
+
+// cliAdd creates an event from flags, from a quick-add line like
+// "standup tomorrow 9:30 @ room 4", or from a line refined by flags.
+func cliAdd(args []string) int {
+	s := newCLISession("add", "[flags] [\"quick-add text\"]")
+	flags := addEventFlags(s.fs)
+	positional, code, ok := s.parse(args)
+	if !ok {
+		return code
+	}
+	set := flags.set()
+	if len(positional) == 0 && !set["start"] && !set["all-day"] {
+		return s.fail(exitUsage, "add needs --start, --all-day or quick-add text")
+	}
+
+	// Dates and times are read in the calendar's zone, known once connected
+	s.connect()
+	var event Event
+	if len(positional) > 0 {
+		parsed, err := ParseQuickAdd(strings.Join(positional, " "), Now())
+		if err != nil {
+			return s.fail(exitUsage, "%v", err)
+		}
+		event = parsed
+	} else {
+		today := StartOfDay(Now())
+		event.Start.DateTime = today
+		event.End.DateTime = today.Add(defaultDuration)
+	}
+	calendars := s.calendars()
+	event, err := flags.apply(event, calendars)
+	if err != nil {
+		return s.fail(exitUsage, "%v", err)
+	}
+	if event.CalendarID == "" {
+		if cal, ok := DefaultCalendar(calendars); ok {
+			event.CalendarID = cal.Id
+		}
+	}
+	created, err := s.backend.CreateEvent(event)
+	if err != nil {
+		return s.fail(exitError, "failed to create event: %v", err)
+	}
+	s.printEvent(os.Stdout, created, calendars)
+	return exitOK
+}
+
+// findEvent looks id up in --calendar, or in every calendar we can see
+// when none is given.
+func (s *cliSession) findEvent(id string, calendarName string, calendars []Calendar) (Event, error) {
+	var candidates []Calendar
+	if calendarName != "" {
+		cal, ok := FindCalendar(calendars, calendarName)
+		if !ok {
+			return Event{}, fmt.Errorf("%w: no calendar named %q", ErrEventNotFound, calendarName)
+		}
+		candidates = []Calendar{cal}
+	} else {
+		for _, cal := range calendars {
+			if cal.Visible {
+				candidates = append(candidates, cal)
+			}
+		}
+	}
+	for _, cal := range candidates {
+		event, err := s.backend.GetEvent(cal.Id, id)
+		if err == nil {
+			return event, nil
+		}
+		if !errors.Is(err, ErrEventNotFound) {
+			return Event{}, err
+		}
+	}
+	return Event{}, fmt.Errorf("%w: %q", ErrEventNotFound, id)
+}
+
+// lookupFailed turns a findEvent error into an exit code.
+func (s *cliSession) lookupFailed(err error) int {
+	if errors.Is(err, ErrEventNotFound) {
+		return s.fail(exitNotFound, "%v", err)
+	}
+	return s.fail(exitError, "failed to look up event: %v", err)
+}
+
+func cliEdit(args []string) int {
+	s := newCLISession("edit", "<id> [flags]")
+	flags := addEventFlags(s.fs)
+	positional, code, ok := s.parse(args)
+	if !ok {
+		return code
+	}
+	if len(positional) != 1 {
+		s.fs.Usage()
+		return exitUsage
+	}
+
+	s.connect()
+	calendars := s.calendars()
+	event, err := s.findEvent(positional[0], *flags.calendar, calendars)
+	if err != nil {
+		return s.lookupFailed(err)
+	}
+	edited, err := flags.apply(event, calendars)
+	if err != nil {
+		return s.fail(exitUsage, "%v", err)
+	}
+	updated, err := s.backend.UpdateEvent(edited)
+	if err != nil {
+		return s.fail(exitError, "failed to update event: %v", err)
+	}
+	s.printEvent(os.Stdout, updated, calendars)
+	return exitOK
+}
+
+func cliDelete(args []string) int {
+	s := newCLISession("delete", "<id> [--calendar name] [--notify all|external|none]")
+	calendarName := s.fs.String("calendar", "", "Calendar name or id, every visible calendar when empty")
+	notify := s.fs.String("notify", "all", "Who to email about the cancellation: all, external or none")
+	positional, code, ok := s.parse(args)
+	if !ok {
+		return code
+	}
+	if len(positional) != 1 {
+		s.fs.Usage()
+		return exitUsage
+	}
+	sendUpdates, err := ParseSendUpdates(*notify)
+	if err != nil {
+		return s.fail(exitUsage, "%v", err)
+	}
+
+	s.connect()
+	calendars := s.calendars()
+	event, err := s.findEvent(positional[0], *calendarName, calendars)
+	if err != nil {
+		return s.lookupFailed(err)
+	}
+	event.SendUpdates = sendUpdates
+	err = s.backend.DeleteEvent(event)
+	if err != nil {
+		return s.fail(exitError, "failed to delete event: %v", err)
+	}
+	s.printEvent(os.Stdout, event, calendars)
+	return exitOK
+}
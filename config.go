@@ -3,6 +3,8 @@ package main
 import (
 	"os"
 	"path/filepath"
+
+	tea "github.com/charmbracelet/bubbletea"
 )
 
 func createConfig() error {
@@ -20,11 +22,26 @@ CALENDAR_ID="email@gmail.com"
 CLIENT_ID="CLIENT_ID"
 CLIENT_SECRET="CLIENT_SECRET"
 REFRESH_TOKEN="REFRESH_TOKEN"
-COLOR_PRIMARY="#7e9cd8"
-COLOR_WARNING="#ffcc00"
-COLOR_ERROR="#FF3333"
+THEME="auto"
+TIME_ZONE=""
+HIDE_DECLINED="n"
+NOTIFY_SNOOZE="10m"
+WORKING_HOURS="8-18"
+WINDOW_DAYS="7"
+WEEK_START="today"
+KEYS="default"
 `)
 	envPath := filepath.Join(configPath, ".env")
 	os.WriteFile(envPath, dump, 0644)
 	return nil
 }
+
+// LogToConfigDir sends log output to {USER_CONFIG}/go-home/go-home.log so
+// background errors do not draw over the TUI.
+func LogToConfigDir() (*os.File, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return nil, err
+	}
+	return tea.LogToFile(filepath.Join(configDir, "go-home", "go-home.log"), "go-home")
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"time"
+)
+
+// Overlaps reports whether two timed events share some time. All-day events
+// and events we declined never conflict with anything.
+func Overlaps(a Event, b Event) bool {
+	if a.AllDay || b.AllDay || (a.Id != "" && a.Id == b.Id) {
+		return false
+	}
+	if SelfResponse(a) == "declined" || SelfResponse(b) == "declined" {
+		return false
+	}
+	return a.Start.DateTime.Before(b.End.DateTime) && b.Start.DateTime.Before(a.End.DateTime)
+}
+
+// Conflicts lists the events that overlap event, each once.
+func Conflicts(event Event, events []Event) []Event {
+	var conflicts []Event
+	seen := make(map[string]bool)
+	for _, other := range events {
+		if seen[other.Id] || !Overlaps(event, other) {
+			continue
+		}
+		seen[other.Id] = true
+		conflicts = append(conflicts, other)
+	}
+	return conflicts
+}
+
+// ConflictingIds is the set of events that overlap at least one other event.
+func ConflictingIds(events []Event) map[string]bool {
+	conflicting := make(map[string]bool)
+	for i, a := range events {
+		for _, b := range events[i+1:] {
+			if Overlaps(a, b) {
+				conflicting[a.Id] = true
+				conflicting[b.Id] = true
+			}
+		}
+	}
+	return conflicting
+}
+
+// formConflicts lists the events the time in the form overlaps, from the
+// loaded events and the cache of every visible calendar. It is empty while
+// the time in the form does not parse.
+func (m Model) formConflicts() []Event {
+	if IsYes(m.inputs[AllDay].Value()) {
+		return nil
+	}
+	loc := CalendarLocation()
+	start, err := time.ParseInLocation("2006-01-02 15:04", m.inputs[Date].Value()+" "+m.inputs[StartTime].Value(), loc)
+	if err != nil {
+		return nil
+	}
+	end, err := time.ParseInLocation("2006-01-02 15:04", m.inputs[EndDate].Value()+" "+m.inputs[EndTime].Value(), loc)
+	if err != nil || !end.After(start) {
+		return nil
+	}
+	proposed := Event{Id: m.formEvent.Id}
+	if m.newEvent {
+		proposed.Id = ""
+	}
+	proposed.Start.DateTime, proposed.End.DateTime = start, end
+
+	candidates := m.loaded
+	if cached, ok := m.backend.(CachedBackend); ok {
+		candidates = append(append([]Event(nil), m.loaded...), VisibleEvents(cached.CachedEvents(start, end))...)
+	}
+	return Conflicts(proposed, candidates)
+}
+
+// conflictsView warns about the events the form overlaps. Saving is still
+// allowed, double booking is sometimes on purpose.
+func (m Model) conflictsView() string {
+	conflicts := m.formConflicts()
+	if len(conflicts) == 0 {
+		return ""
+	}
+	s := style.warningStyle.Render("Overlaps with:") + "\n"
+	for _, event := range conflicts {
+		line := "  " + event.Summary + "  " + EventWhen(event)
+		if name := m.calendarName(event); name != "" {
+			line += " (" + name + ")"
+		}
+		s += style.warningStyle.Render(line) + "\n"
+	}
+	return s
+}
+
+// ConflictSummary is the card title, marked with ⚠ when the event overlaps
+// another one.
+func ConflictSummary(event Event, conflicting map[string]bool) string {
+	if conflicting[event.Id] {
+		return "⚠ " + CardSummary(event)
+	}
+	return CardSummary(event)
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// TimeRange is a stretch of time, End excluded.
+type TimeRange struct {
+	Start time.Time
+	End   time.Time
+}
+
+type FreeBusyRequestType struct {
+	TimeMin string `json:"timeMin"`
+	TimeMax string `json:"timeMax"`
+	Items   []struct {
+		Id string `json:"id"`
+	} `json:"items"`
+}
+
+type FreeBusyResponseType struct {
+	Calendars map[string]struct {
+		Busy []struct {
+			Start string `json:"start"`
+			End   string `json:"end"`
+		} `json:"busy"`
+		Errors []struct {
+			Reason string `json:"reason"`
+		} `json:"errors"`
+	} `json:"calendars"`
+}
+
+// GetFreeBusy asks Google when the calendars are busy between timeMin and
+// timeMax.
+func GetFreeBusy(config apiConfig, calendarIDs []string, timeMin time.Time, timeMax time.Time) ([]TimeRange, error) {
+	var request FreeBusyRequestType
+	request.TimeMin = timeMin.UTC().Format(time.RFC3339)
+	request.TimeMax = timeMax.UTC().Format(time.RFC3339)
+	for _, id := range calendarIDs {
+		request.Items = append(request.Items, struct {
+			Id string `json:"id"`
+		}{Id: id})
+	}
+	payload, err := json.Marshal(request)
+	if err != nil {
+		log.Printf("POST /freeBusy Error marshaling request %v\n", err)
+		return nil, err
+	}
+	req, err := http.NewRequest("POST", "https://www.googleapis.com/calendar/v3/freeBusy", bytes.NewBuffer(payload))
+	if err != nil {
+		log.Printf("POST /freeBusy Error creating new req %v\n", err)
+		return nil, err
+	}
+	req.Header.Set("Authorization", config.accessToken)
+	req.Header.Set("Content-Type", "application/json")
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		log.Printf("POST /freeBusy Error making request %v\n", err)
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		log.Printf("POST /freeBusy Error reading body %v\n", err)
+		return nil, err
+	}
+	if res.StatusCode != http.StatusOK {
+		log.Printf("POST /freeBusy Error failed with status code %v\n with body %v\n", res.StatusCode, string(body))
+		if res.StatusCode == http.StatusForbidden {
+			// Tokens from before free time search lack the free/busy scope
+			return nil, fmt.Errorf("POST /freeBusy is not allowed, run go-home with -a again")
+		}
+		return nil, fmt.Errorf("POST /freeBusy failed with status code %d", res.StatusCode)
+	}
+
+	var response FreeBusyResponseType
+	err = json.Unmarshal(body, &response)
+	if err != nil {
+		log.Printf("POST /freeBusy Error unmarshaling body %v\n", err)
+		return nil, err
+	}
+	var busy []TimeRange
+	for id, calendar := range response.Calendars {
+		if len(calendar.Errors) > 0 {
+			log.Printf("POST /freeBusy Error for calendar %v: %v\n", id, calendar.Errors[0].Reason)
+			return nil, fmt.Errorf("could not read when %s is busy: %s", id, calendar.Errors[0].Reason)
+		}
+		for _, period := range calendar.Busy {
+			start, err := time.Parse(time.RFC3339, period.Start)
+			if err != nil {
+				return nil, err
+			}
+			end, err := time.Parse(time.RFC3339, period.End)
+			if err != nil {
+				return nil, err
+			}
+			busy = append(busy, TimeRange{Start: start, End: end})
+		}
+	}
+	return busy, nil
+}
+
+// BusyFromEvents is when the timed events we have not declined take place.
+func BusyFromEvents(events []Event) []TimeRange {
+	var busy []TimeRange
+	for _, event := range events {
+		if event.AllDay || SelfResponse(event) == "declined" {
+			continue
+		}
+		busy = append(busy, TimeRange{Start: event.Start.DateTime, End: event.End.DateTime})
+	}
+	return busy
+}
+
+// FindTimeQuery is what the free-slot finder looks for: a length of time
+// between From and To minutes after midnight, on the days First to Last.
+type FindTimeQuery struct {
+	Duration time.Duration
+	From     int
+	To       int
+	First    time.Time
+	Last     time.Time
+}
+
+// Bounds is the time the query covers.
+func (q FindTimeQuery) Bounds() (time.Time, time.Time) {
+	return q.First, q.Last.AddDate(0, 0, 1)
+}
+
+// ParseFindTime reads a query like "1h", "30m 9-12 tomorrow" or
+// "45m mon-fri". The length defaults to 30 minutes, the hours to
+// WORKING_HOURS and the days to the ones on screen from today on.
+func ParseFindTime(text string) (FindTimeQuery, error) {
+	query := FindTimeQuery{Duration: slotMinutes * time.Minute, From: workStart, To: workEnd}
+	today := StartOfDay(Now())
+	tokens := strings.Fields(strings.ToLower(text))
+	var days []time.Time
+	for i := 0; i < len(tokens); {
+		if day, used, ok := parseDay(tokens[i:], today); ok {
+			days = append(days, day)
+			i += used
+			continue
+		}
+		if tokens[i] == "to" || tokens[i] == "until" || tokens[i] == "-" {
+			i++
+			continue
+		}
+		// "mon-fri" names both days in one word
+		if first, last, ok := strings.Cut(tokens[i], "-"); ok {
+			firstDay, _, okFirst := parseDay([]string{first}, today)
+			lastDay, _, okLast := parseDay([]string{last}, today)
+			if okFirst && okLast {
+				days = append(days, firstDay, lastDay)
+				i++
+				continue
+			}
+		}
+		if d, used, ok := parseDuration(tokens[i:]); ok {
+			query.Duration = d
+			i += used
+			continue
+		}
+		if from, to, err := ParseWorkingHours(tokens[i]); err == nil {
+			query.From, query.To = from, to
+			i++
+			continue
+		}
+		return FindTimeQuery{}, fmt.Errorf("%q is not a length, hours or day", tokens[i])
+	}
+
+	switch len(days) {
+	case 0:
+		query.First = WindowStart()
+		if query.First.Before(today) {
+			query.First = today
+		}
+		query.Last = WindowStart().AddDate(0, 0, WindowDays()-1)
+	case 1:
+		query.First, query.Last = days[0], days[0]
+	case 2:
+		query.First, query.Last = days[0], days[1]
+		if query.Last.Before(query.First) {
+			// "fri-mon" runs over the weekend
+			query.Last = query.Last.AddDate(0, 0, 7)
+		}
+	default:
+		return FindTimeQuery{}, fmt.Errorf("give at most two days, the first and last to look at")
+	}
+	if query.Last.Before(today) {
+		return FindTimeQuery{}, fmt.Errorf("those days are in the past")
+	}
+	if query.Duration > time.Duration(query.To-query.From)*time.Minute {
+		return FindTimeQuery{}, fmt.Errorf("%s does not fit between %s and %s", FormatDuration(query.Duration),
+			atMinutes(today, query.From).Format("15:04"), atMinutes(today, query.To).Format("15:04"))
+	}
+	return query, nil
+}
+
+// FreeSlots are the gaps of at least query.Duration between the busy times,
+// inside the hours of the query on each of its days. Times before now are
+// never free.
+func FreeSlots(query FindTimeQuery, busy []TimeRange, now time.Time) []TimeRange {
+	busy = append([]TimeRange(nil), busy...)
+	sort.Slice(busy, func(i, j int) bool {
+		return busy[i].Start.Before(busy[j].Start)
+	})
+
+	// Free time starts on a quarter hour
+	earliest := now.Truncate(15 * time.Minute)
+	if earliest.Before(now) {
+		earliest = earliest.Add(15 * time.Minute)
+	}
+
+	var free []TimeRange
+	for day := query.First; !day.After(query.Last); day = day.AddDate(0, 0, 1) {
+		start := atMinutes(day, query.From)
+		end := atMinutes(day, query.To)
+		if start.Before(earliest) {
+			start = earliest
+		}
+		for _, period := range busy {
+			if !period.End.After(start) {
+				continue
+			}
+			if !period.Start.Before(end) {
+				break
+			}
+			if period.Start.Sub(start) >= query.Duration {
+				free = append(free, TimeRange{Start: start, End: period.Start})
+			}
+			if period.End.After(start) {
+				start = period.End
+			}
+		}
+		if end.Sub(start) >= query.Duration {
+			free = append(free, TimeRange{Start: start, End: end})
+		}
+	}
+	return free
+}
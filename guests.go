@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"net/mail"
+	"strings"
+)
+
+// Who is told about a change, as the API's sendUpdates parameter.
+const (
+	notifyAll      = "all"
+	notifyExternal = "externalOnly"
+	notifyNone     = "none"
+)
+
+// GuestList is the guests of event the form shows, everyone except us.
+func GuestList(event Event) string {
+	var emails []string
+	for _, attendee := range event.Attendees {
+		if !attendee.Self {
+			emails = append(emails, attendee.Email)
+		}
+	}
+	return strings.Join(emails, ", ")
+}
+
+// ParseGuests reads a comma or space separated list of email addresses.
+func ParseGuests(value string) ([]string, error) {
+	var emails []string
+	for _, field := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == ' ' || r == ';' }) {
+		address, err := mail.ParseAddress(field)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not an email address", field)
+		}
+		emails = append(emails, address.Address)
+	}
+	return emails, nil
+}
+
+// MergeAttendees is the guest list with emails invited. Guests who were
+// already invited keep their response, and we are never removed.
+func MergeAttendees(existing []Attendee, emails []string) []Attendee {
+	if len(emails) == 0 {
+		// Without guests the event is ours alone again
+		return nil
+	}
+	known := make(map[string]Attendee)
+	var merged []Attendee
+	for _, attendee := range existing {
+		if attendee.Self {
+			merged = append(merged, attendee)
+			continue
+		}
+		known[strings.ToLower(attendee.Email)] = attendee
+	}
+	for _, email := range emails {
+		if attendee, ok := known[strings.ToLower(email)]; ok {
+			merged = append(merged, attendee)
+			continue
+		}
+		merged = append(merged, Attendee{Email: email, ResponseStatus: "needsAction"})
+	}
+	return merged
+}
+
+// GuestPermissions describes what guests may do, like "invite,see".
+func GuestPermissions(event Event) string {
+	var permissions []string
+	if event.GuestsCanModify {
+		permissions = append(permissions, "modify")
+	}
+	if event.GuestsCanInviteOthers {
+		permissions = append(permissions, "invite")
+	}
+	if event.GuestsCanSeeOtherGuests {
+		permissions = append(permissions, "see")
+	}
+	return strings.Join(permissions, ",")
+}
+
+// ParseGuestPermissions reads a list of modify, invite and see. "none"
+// takes every permission away.
+func ParseGuestPermissions(value string) (modify bool, invite bool, see bool, err error) {
+	for _, field := range strings.FieldsFunc(strings.ToLower(value), func(r rune) bool { return r == ',' || r == ' ' }) {
+		switch field {
+		case "modify", "edit":
+			modify = true
+		case "invite":
+			invite = true
+		case "see", "list":
+			see = true
+		case "none":
+		default:
+			return false, false, false, fmt.Errorf("unknown guest permission %q", field)
+		}
+	}
+	return modify, invite, see, nil
+}
+
+// ParseSendUpdates reads who to notify: all, external or none.
+func ParseSendUpdates(value string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "", "all", "y", "yes":
+		return notifyAll, nil
+	case "external", "externalonly":
+		return notifyExternal, nil
+	case "none", "n", "no":
+		return notifyNone, nil
+	}
+	return "", fmt.Errorf("notify must be all, external or none")
+}
@@ -20,7 +20,7 @@ func (config *apiConfig) startOauthFlow(w http.ResponseWriter, r *http.Request)
 			"scope=%s&access_type=offline&prompt=consent",
 		config.clientID,
 		url.QueryEscape("http://localhost:8080/auth/callback"),
-		url.QueryEscape("https://www.googleapis.com/auth/calendar.events"))
+		url.QueryEscape("https://www.googleapis.com/auth/calendar.events https://www.googleapis.com/auth/calendar.events.freebusy https://www.googleapis.com/auth/calendar.calendarlist.readonly"))
 
 	http.Redirect(w, r, authURL, http.StatusTemporaryRedirect)
 }
@@ -4,37 +4,52 @@ import (
 	"fmt"
 	"os/exec"
 	"runtime"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
 )
 
-func GetDaysStartingToday() []string {
-	allDays := []string{
-		"Sun",
-		"Mon",
-		"Tue",
-		"Wed",
-		"Thu",
-		"Fri",
-		"Sat",
-	}
-	today := int(time.Now().Weekday())
-	return append(allDays[today:], allDays[:today]...)
+func GetWindowDays() []string {
+	allDays := []string{}
+	start := WindowStart()
+	for i := range WindowDays() {
+		allDays = append(allDays, start.AddDate(0, 0, i).Format("Mon"))
+	}
+	return allDays
 }
 
-func GetDateStartingToday() []int {
+func GetWindowDates() []int {
 	allDates := []int{}
-	today := time.Now()
-	for i := range 7 {
-		allDates = append(allDates, today.AddDate(0, 0, i).Day())
+	start := WindowStart()
+	for i := range WindowDays() {
+		allDates = append(allDates, start.AddDate(0, 0, i).Day())
 	}
 	return allDates
 }
 
+// CurrentWindow is the days on screen, from midnight of the first to
+// midnight after the last.
+func CurrentWindow() (time.Time, time.Time) {
+	start := WindowStart()
+	return start, start.AddDate(0, 0, WindowDays())
+}
+
+func SortEvents(events []Event) {
+	sort.Slice(events, func(i, j int) bool {
+		if events[i].Start.DateTime.Equal(events[j].Start.DateTime) {
+			return events[i].Id < events[j].Id
+		}
+		return events[i].Start.DateTime.Before(events[j].Start.DateTime)
+	})
+}
+
 func CreateEventMatrix(events []Event) [][]Event {
 	rows := EventRowCount(events)
-	cols := 7
+	cols := WindowDays()
 	eventMatrix := make([][]Event, rows)
 
 	for i := range eventMatrix {
@@ -43,52 +58,94 @@ func CreateEventMatrix(events []Event) [][]Event {
 
 	dayMap := make(map[int]int)
 	for _, event := range events {
-		eventIndex := DateToIndex(event.Start.Date)
+		if event.AllDay {
+			continue
+		}
+		eventIndex := DateToIndex(EventDate(event))
 		if eventIndex >= 0 && eventIndex < cols && dayMap[eventIndex] < rows {
 			eventMatrix[dayMap[eventIndex]][eventIndex] = event
 			dayMap[eventIndex]++
 		}
 	}
 
-	addEventCards := make([]Event, 7)
+	addEventCards := make([]Event, cols)
 	for i := range addEventCards {
 		addEventCards[i].Summary = "+"
 	}
 	eventMatrix = append([][]Event{addEventCards}, eventMatrix...)
 	return eventMatrix
 }
+
+// BannerSpan is an all-day event clipped to the columns it covers.
+type BannerSpan struct {
+	Event Event
+	Start int
+	End   int
+}
+
+// CreateAllDayLanes stacks all-day events into lanes so events that cover
+// the same day never share a lane.
+func CreateAllDayLanes(events []Event) [][]BannerSpan {
+	var lanes [][]BannerSpan
+	first := WindowStart()
+	last := WindowDays() - 1
+	for _, event := range events {
+		if !event.AllDay {
+			continue
+		}
+		start, _ := time.ParseInLocation("2006-01-02", EventDate(event), CalendarLocation())
+		end, _ := time.ParseInLocation("2006-01-02", EventEndDate(event), CalendarLocation())
+		span := BannerSpan{
+			Event: event,
+			Start: max(DaysBetween(first, start), 0),
+			End:   min(DaysBetween(first, end), last),
+		}
+		if span.End < 0 || span.Start > last || span.Start > span.End {
+			continue
+		}
+		placed := false
+		for i, lane := range lanes {
+			if lane[len(lane)-1].End < span.Start {
+				lanes[i] = append(lanes[i], span)
+				placed = true
+				break
+			}
+		}
+		if !placed {
+			lanes = append(lanes, []BannerSpan{span})
+		}
+	}
+	return lanes
+}
 func EventRowCount(events []Event) int {
 	countMap := make(map[int]int)
 	maxCount := 0
 	for _, event := range events {
-		countMap[DateToIndex(event.Start.DateTime.Format("2006-01-02"))]++
-		if countMap[DateToIndex(event.Start.DateTime.Format("2006-01-02"))] > maxCount {
+		if event.AllDay {
+			continue
+		}
+		countMap[DateToIndex(EventDate(event))]++
+		if countMap[DateToIndex(EventDate(event))] > maxCount {
 			maxCount++
 		}
 	}
 	return maxCount
 }
 func DateToIndex(date string) int {
-	targetDate, err := time.Parse("2006-01-02", date)
+	targetDate, err := time.ParseInLocation("2006-01-02", date, CalendarLocation())
 	if err != nil {
 		return -1
 	}
 
-	now := time.Now()
-	currentDate := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
-
-	targetDate = time.Date(targetDate.Year(), targetDate.Month(), targetDate.Day(), 0, 0, 0, 0, now.Location())
-
-	diff := targetDate.Sub(currentDate)
-	days := int(diff.Hours() / 24)
+	days := DaysBetween(WindowStart(), targetDate)
 
-	if days < 0 || days > 7 {
+	if days < 0 || days >= WindowDays() {
 		return -1
 	}
 
 	return days
 }
-func FormsValidation(inputs []textinput.Model, validFields *[]bool) bool {
+func FormsValidation(inputs []textinput.Model, validFields *[]bool, calendars []Calendar) bool {
 	for i := range *validFields {
 		(*validFields)[i] = true
 	}
@@ -100,43 +157,113 @@ func FormsValidation(inputs []textinput.Model, validFields *[]bool) bool {
 		invalid = true
 	}
 	date := inputs[Date].Value()
+	endDate := inputs[EndDate].Value()
 	startTime := inputs[StartTime].Value()
 	endTime := inputs[EndTime].Value()
+	allDay := inputs[AllDay].Value()
 
-	_, err := time.Parse("2006-01-02", date)
+	d1, err := time.Parse("2006-01-02", date)
 	if err != nil {
 		(*validFields)[Date] = false
 		invalid = true
 	}
-	t1, err := time.Parse("15:04", startTime)
+	d2, err := time.Parse("2006-01-02", endDate)
 	if err != nil {
-		(*validFields)[StartTime] = false
+		(*validFields)[EndDate] = false
 		invalid = true
 	}
-	t2, err := time.Parse("15:04", endTime)
-	if err != nil {
-		(*validFields)[EndTime] = false
+	if d1.After(d2) {
+		(*validFields)[EndDate] = false
 		invalid = true
 	}
-	if t1.Compare(t2) == +1 {
-		(*validFields)[EndTime] = false
+	switch strings.ToLower(strings.TrimSpace(allDay)) {
+	case "", "y", "yes", "true", "n", "no", "false":
+	default:
+		(*validFields)[AllDay] = false
 		invalid = true
 	}
+	if !IsYes(allDay) {
+		t1, err := time.Parse("15:04", startTime)
+		if err != nil {
+			(*validFields)[StartTime] = false
+			invalid = true
+		}
+		t2, err := time.Parse("15:04", endTime)
+		if err != nil {
+			(*validFields)[EndTime] = false
+			invalid = true
+		}
+		if d1.Equal(d2) && t1.Compare(t2) >= 0 {
+			(*validFields)[EndTime] = false
+			invalid = true
+		}
+	}
+	calendarName := inputs[CalendarName].Value()
+	if _, ok := FindCalendar(calendars, calendarName); calendarName != "" && len(calendars) > 0 && !ok {
+		(*validFields)[CalendarName] = false
+		invalid = true
+	}
+	if _, err := ParseGuests(inputs[Guests].Value()); err != nil {
+		(*validFields)[Guests] = false
+		invalid = true
+	}
+	if _, _, _, err := ParseGuestPermissions(inputs[Permissions].Value()); err != nil {
+		(*validFields)[Permissions] = false
+		invalid = true
+	}
+	switch strings.ToLower(strings.TrimSpace(inputs[Video].Value())) {
+	case "", "y", "yes", "true", "n", "no", "false":
+	default:
+		(*validFields)[Video] = false
+		invalid = true
+	}
+	if _, err := ParseSendUpdates(inputs[Notify].Value()); err != nil {
+		(*validFields)[Notify] = false
+		invalid = true
+	}
+	if _, _, err := ParseReminders(inputs[Reminders].Value()); err != nil {
+		(*validFields)[Reminders] = false
+		invalid = true
+	}
+	if repeat := inputs[Repeat].Value(); strings.TrimSpace(repeat) != "" {
+		if _, err := ParseRepeat(repeat, IsYes(allDay)); err != nil {
+			(*validFields)[Repeat] = false
+			invalid = true
+		}
+	}
 
 	return invalid
 }
+
+// Truncate cuts s to maxLen columns of the terminal, never inside a
+// character, so titles with markers like ✔ or wide letters stay readable.
 func Truncate(s string, maxLen int, elipse bool) string {
-	if len(s) <= maxLen {
+	if lipgloss.Width(s) <= maxLen {
 		return s
 	}
 	if elipse {
-		return s[:maxLen-3] + "\n..."
+		return ansi.Truncate(s, maxLen-3, "") + "\n..."
+	}
+	return ansi.Truncate(s, maxLen, "")
+}
+
+// CardSummary is the title shown on a card, marked when the change behind
+// it has not reached the calendar yet.
+func CardSummary(event Event) string {
+	summary := event.Summary
+	switch SelfResponse(event) {
+	case "needsAction":
+		summary = "✉ " + summary
+	case "tentative":
+		summary = "? " + summary
+	}
+	if event.Pending {
+		return "⟳ " + summary
 	}
-	return s[:maxLen]
+	return summary
 }
 func NewEventDate(i int) string {
-	now := time.Now()
-	eventDate := now.AddDate(0, 0, i)
+	eventDate := WindowStart().AddDate(0, 0, i)
 	return eventDate.Format("2006-01-02")
 }
 func OpenUrl(url string) error {
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"testing"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		s      string
+		maxLen int
+		elipse bool
+		want   string
+	}{
+		{"Standup", 10, false, "Standup"},
+		{"Design review", 6, false, "Design"},
+		{"✔ ⚠ Lunch with Sam", 5, false, "✔ ⚠ L"},
+		{"✔ Lunch", 1, false, "✔"},
+		{"会議の準備", 5, false, "会議"},
+		{"Quarterly planning with the whole team", 25, true, "Quarterly planning wit\n..."},
+		{"✔ ⚠ Quarterly planning with everyone", 25, true, "✔ ⚠ Quarterly planning\n..."},
+		{"✔ Short", 25, true, "✔ Short"},
+		{"Lunch", 0, false, ""},
+	}
+	for _, tt := range tests {
+		got := Truncate(tt.s, tt.maxLen, tt.elipse)
+		if got != tt.want {
+			t.Errorf("Truncate(%q, %d, %v) = %q, want %q", tt.s, tt.maxLen, tt.elipse, got, tt.want)
+		}
+		if !utf8.ValidString(got) {
+			t.Errorf("Truncate(%q, %d, %v) split a character", tt.s, tt.maxLen, tt.elipse)
+		}
+		if !tt.elipse && lipgloss.Width(got) > tt.maxLen {
+			t.Errorf("Truncate(%q, %d) is %d wide", tt.s, tt.maxLen, lipgloss.Width(got))
+		}
+	}
+}
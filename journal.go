@@ -0,0 +1,169 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	opCreate  = "create"
+	opUpdate  = "update"
+	opDelete  = "delete"
+	opRespond = "respond"
+	opMove    = "move"
+)
+
+// JournalEntry is one queued change. Destination is the calendar a move
+// goes to, Event.CalendarID is still the one it comes from.
+type JournalEntry struct {
+	Op          string    `json:"op"`
+	Event       Event     `json:"event"`
+	Destination string    `json:"destination,omitempty"`
+	QueuedAt    time.Time `json:"queuedAt"`
+}
+
+// Journal is the durable queue of changes made while offline. It is written
+// to disk on every change so nothing is lost if go-home is closed before
+// the network comes back.
+type Journal struct {
+	mu      sync.Mutex
+	path    string
+	Entries []JournalEntry `json:"entries"`
+}
+
+func JournalPath() (string, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "go-home", "journal.json"), nil
+}
+
+func LoadJournal() (*Journal, error) {
+	path, err := JournalPath()
+	if err != nil {
+		return nil, err
+	}
+	journal := &Journal{path: path}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return journal, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(data, journal)
+	if err != nil {
+		return nil, err
+	}
+	return journal, nil
+}
+
+func (j *Journal) save() error {
+	data, err := json.Marshal(j)
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(j.path), 0755)
+	if err != nil {
+		return err
+	}
+	tmp := j.path + ".tmp"
+	err = os.WriteFile(tmp, data, 0644)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp, j.path)
+}
+
+func (j *Journal) Len() int {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return len(j.Entries)
+}
+
+func (j *Journal) Snapshot() []JournalEntry {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return append([]JournalEntry(nil), j.Entries...)
+}
+
+// Append queues a change. Changes to an event that was itself created
+// offline are folded into the queued create so replay stays simple.
+func (j *Journal) Append(op string, event Event) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if IsLocalId(event.Id) {
+		for i, entry := range j.Entries {
+			if entry.Event.Id != event.Id {
+				continue
+			}
+			switch op {
+			case opUpdate, opRespond:
+				j.Entries[i].Event = event
+			case opDelete:
+				j.Entries = append(j.Entries[:i], j.Entries[i+1:]...)
+			}
+			return j.save()
+		}
+	}
+	j.Entries = append(j.Entries, JournalEntry{Op: op, Event: event, QueuedAt: time.Now()})
+	return j.save()
+}
+
+// AppendMove queues moving event to the calendar destination. An event
+// created offline is simply created there instead.
+func (j *Journal) AppendMove(event Event, destination string) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if IsLocalId(event.Id) {
+		for i, entry := range j.Entries {
+			if entry.Event.Id == event.Id {
+				j.Entries[i].Event.CalendarID = destination
+				return j.save()
+			}
+		}
+	}
+	j.Entries = append(j.Entries, JournalEntry{Op: opMove, Event: event, Destination: destination, QueuedAt: time.Now()})
+	return j.save()
+}
+
+// PopFront drops the oldest entry once it has been replayed.
+func (j *Journal) PopFront() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if len(j.Entries) == 0 {
+		return nil
+	}
+	j.Entries = j.Entries[1:]
+	return j.save()
+}
+
+// RenameId points queued entries at the id the backend assigned to an
+// event that was created offline.
+func (j *Journal) RenameId(oldId string, newId string) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	for i := range j.Entries {
+		if j.Entries[i].Event.Id == oldId {
+			j.Entries[i].Event.Id = newId
+		}
+	}
+	return j.save()
+}
+
+func IsLocalId(id string) bool {
+	return strings.HasPrefix(id, "local-")
+}
+
+func NewLocalId() string {
+	return fmt.Sprintf("local-%d", time.Now().UnixNano())
+}
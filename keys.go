@@ -0,0 +1,204 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+)
+
+// Where key actions work. Two actions can share a key when they never work
+// in the same place.
+const (
+	inGrid = 1 << iota
+	inAgenda
+	inTimeGrid
+	inDetail
+	// inPickers is the form and the calendar, batch and recurrence pickers
+	inPickers
+	inCalendar = inGrid | inAgenda | inTimeGrid
+	everywhere = inCalendar | inDetail | inPickers
+)
+
+// keyAction is a binding that can be set in the config as KEY_<name>.
+// where is the set of places it works in.
+type keyAction struct {
+	name    string
+	binding *key.Binding
+	where   int
+}
+
+func (k *keyMap) actions() []keyAction {
+	return []keyAction{
+		{"UP", &k.Up, inCalendar | inPickers},
+		{"DOWN", &k.Down, inCalendar | inPickers},
+		{"LEFT", &k.Left, inCalendar},
+		{"RIGHT", &k.Right, inCalendar},
+		{"OPEN", &k.Open, inCalendar | inPickers},
+		{"FLIP", &k.Flip, inGrid},
+		{"CALENDARS", &k.Calendars, inCalendar | inPickers},
+		{"QUICK_ADD", &k.QuickAdd, inCalendar},
+		{"DETAILS", &k.Details, inCalendar | inDetail},
+		{"RSVP", &k.Rsvp, inCalendar | inDetail},
+		{"EDIT", &k.Edit, inDetail},
+		{"JOIN", &k.Join, inDetail},
+		{"LINK", &k.Link, inDetail},
+		{"SOURCE", &k.Source, inDetail},
+		{"VIEW", &k.View, inCalendar},
+		{"DAY_WEEK", &k.DayWeek, inTimeGrid},
+		{"CYCLE", &k.Cycle, inTimeGrid},
+		{"NEW_AT", &k.NewAt, inTimeGrid},
+		{"PREV", &k.Prev, inCalendar},
+		{"NEXT", &k.Next, inCalendar},
+		{"TODAY", &k.Today, inCalendar},
+		{"GOTO", &k.Goto, inCalendar},
+		{"FIND_TIME", &k.FindTime, inCalendar},
+		{"SEARCH", &k.Search, inCalendar},
+		{"NEXT_MATCH", &k.NextMatch, inCalendar},
+		{"PREV_MATCH", &k.PrevMatch, inCalendar},
+		{"CLEAR", &k.Clear, inCalendar},
+		{"EARLIER", &k.Earlier, inCalendar},
+		{"LATER", &k.Later, inCalendar},
+		{"DAY_BEFORE", &k.DayBefore, inCalendar},
+		{"DAY_AFTER", &k.DayAfter, inCalendar},
+		{"LONGER", &k.Longer, inCalendar},
+		{"SHORTER", &k.Shorter, inCalendar},
+		{"DUPLICATE", &k.Duplicate, inCalendar},
+		{"MARK", &k.Mark, inCalendar},
+		{"BATCH", &k.Batch, inCalendar | inPickers},
+		{"UNDO", &k.Undo, inCalendar},
+		{"REDO", &k.Redo, inCalendar},
+		{"HELP", &k.Help, everywhere},
+		{"QUIT", &k.Quit, everywhere},
+	}
+}
+
+// sharedKeys are actions that may use the same key because they are never
+// on together: new at slot is off while n and N step through matches.
+var sharedKeys = map[[2]string]bool{
+	{"NEW_AT", "NEXT_MATCH"}: true,
+	{"NEW_AT", "PREV_MATCH"}: true,
+}
+
+// fixedKeys move between fields and choices, confirm and go back in the
+// form, the details and the pickers, and in the prompts where text is typed.
+// They cannot be rebound, and an action that works in those places may only
+// use them for what they already do, which are the actions listed.
+var fixedKeys = map[string][]string{
+	"enter":     {"OPEN", "EDIT"},
+	"esc":       {"DETAILS", "CALENDARS", "BATCH"},
+	"tab":       {"DOWN"},
+	"shift+tab": {"UP"},
+	"up":        {"UP"},
+	"down":      {"DOWN"},
+}
+
+// keyPresets are the layouts KEYS picks from, as changes to the default one
+// that takes both arrows and h/j/k/l.
+var keyPresets = map[string]map[string][]string{
+	"default": {},
+	"vim": {
+		"UP": {"k"}, "DOWN": {"j"}, "LEFT": {"h"}, "RIGHT": {"l"},
+		"EARLIER": {"K"}, "LATER": {"J"}, "DAY_BEFORE": {"H"}, "DAY_AFTER": {"L"},
+	},
+	"arrows": {
+		"UP": {"up"}, "DOWN": {"down"}, "LEFT": {"left"}, "RIGHT": {"right"},
+		"EARLIER": {"shift+up"}, "LATER": {"shift+down"}, "DAY_BEFORE": {"shift+left"}, "DAY_AFTER": {"shift+right"},
+	},
+}
+
+// LoadKeys builds the key bindings from a preset and the KEY_<action>
+// overrides found with lookup, like KEY_SEARCH="/,ctrl+f". "none" leaves an
+// action without a key.
+func LoadKeys(preset string, lookup func(string) string) (keyMap, error) {
+	k := keys
+	preset = strings.ToLower(strings.TrimSpace(preset))
+	if preset == "" {
+		preset = "default"
+	}
+	changes, ok := keyPresets[preset]
+	if !ok {
+		return k, fmt.Errorf("unknown KEYS preset %q, use default, vim or arrows", preset)
+	}
+	actions := k.actions()
+	for _, action := range actions {
+		bound, ok := changes[action.name]
+		if value := strings.TrimSpace(lookup("KEY_" + action.name)); value != "" {
+			bound, ok = ParseKeys(value), true
+		}
+		if !ok {
+			continue
+		}
+		action.binding.SetKeys(bound...)
+		action.binding.SetHelp(KeysLabel(bound), action.binding.Help().Desc)
+	}
+	return k, KeyConflicts(actions)
+}
+
+// ParseKeys reads a comma separated list of keys. "space" is the space bar.
+func ParseKeys(value string) []string {
+	var bound []string
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		switch strings.ToLower(name) {
+		case "", "none":
+			continue
+		case "space":
+			name = " "
+		}
+		bound = append(bound, name)
+	}
+	return bound
+}
+
+// KeysLabel is how keys are shown in the help.
+func KeysLabel(bound []string) string {
+	symbols := strings.NewReplacer("up", "↑", "down", "↓", "left", "←", "right", "→", "shift+", "⇧")
+	var labels []string
+	for _, name := range bound {
+		if name == " " {
+			labels = append(labels, "space")
+		} else {
+			labels = append(labels, symbols.Replace(name))
+		}
+	}
+	return strings.Join(labels, "/")
+}
+
+// KeyConflicts reports the first key two actions share in a place they both
+// work in. ctrl+c is kept for quitting, and the fixed keys for what they do.
+func KeyConflicts(actions []keyAction) error {
+	for i, a := range actions {
+		for _, name := range a.binding.Keys() {
+			if name == "ctrl+c" && a.name != "QUIT" {
+				return fmt.Errorf("KEY_%s uses ctrl+c, which always quits", a.name)
+			}
+			if allowed, ok := fixedKeys[name]; ok && a.where&(inDetail|inPickers) != 0 && !slices.Contains(allowed, a.name) {
+				return fmt.Errorf("KEY_%s uses %s, which is kept for moving, confirming and going back in the form, details and pickers", a.name, name)
+			}
+			for _, b := range actions[i+1:] {
+				if a.where&b.where == 0 {
+					continue
+				}
+				if sharedKeys[[2]string{a.name, b.name}] || sharedKeys[[2]string{b.name, a.name}] {
+					continue
+				}
+				for _, other := range b.binding.Keys() {
+					if other == name {
+						return fmt.Errorf("KEY_%s and KEY_%s both use %q", a.name, b.name, name)
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// keyHint is the first key of a binding, for hints in the views.
+func keyHint(binding key.Binding) string {
+	if len(binding.Keys()) == 0 {
+		return "?"
+	}
+	return KeysLabel(binding.Keys()[:1])
+}
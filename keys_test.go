@@ -0,0 +1,82 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLoadKeys(t *testing.T) {
+	tests := []struct {
+		preset string
+		config map[string]string
+		action string
+		want   []string
+		ok     bool
+	}{
+		{"", nil, "UP", []string{"up", "k"}, true},
+		{"vim", nil, "UP", []string{"k"}, true},
+		{"Arrows", nil, "EARLIER", []string{"shift+up"}, true},
+		{"default", map[string]string{"KEY_SEARCH": "/, ctrl+f"}, "SEARCH", []string{"/", "ctrl+f"}, true},
+		{"default", map[string]string{"KEY_MARK": "space", "KEY_OPEN": "enter"}, "MARK", []string{" "}, true},
+		{"default", map[string]string{"KEY_UNDO": "none"}, "UNDO", nil, true},
+		{"vim", map[string]string{"KEY_UP": "w"}, "UP", []string{"w"}, true},
+		// Flip is only in the grid and day/week only in the time grid
+		{"default", map[string]string{"KEY_FLIP": "d"}, "FLIP", []string{"d"}, true},
+		// Detail keys only clash with what works in the details
+		{"default", map[string]string{"KEY_JOIN": "u"}, "JOIN", []string{"u"}, true},
+		{"default", map[string]string{"KEY_RSVP": "e"}, "", nil, false},
+		// Fixed keys may only be bound to what they already do
+		{"default", map[string]string{"KEY_UP": "up,shift+tab"}, "UP", []string{"up", "shift+tab"}, true},
+		{"default", map[string]string{"KEY_DETAILS": "i,esc", "KEY_CLEAR": "backspace"}, "DETAILS", []string{"i", "esc"}, true},
+		{"default", map[string]string{"KEY_UNDO": "shift+tab"}, "UNDO", []string{"shift+tab"}, true},
+		{"default", map[string]string{"KEY_HELP": "tab"}, "", nil, false},
+		{"default", map[string]string{"KEY_EDIT": "esc"}, "", nil, false},
+		{"default", map[string]string{"KEY_UP": "enter"}, "", nil, false},
+		{"emacs", nil, "", nil, false},
+		{"default", map[string]string{"KEY_SEARCH": "u"}, "", nil, false},
+		{"default", map[string]string{"KEY_DETAILS": "d"}, "", nil, false},
+		{"default", map[string]string{"KEY_HELP": "ctrl+c"}, "", nil, false},
+	}
+	for _, tt := range tests {
+		k, err := LoadKeys(tt.preset, func(name string) string { return tt.config[name] })
+		if (err == nil) != tt.ok {
+			t.Errorf("LoadKeys(%q, %v) error %v, want ok %v", tt.preset, tt.config, err, tt.ok)
+			continue
+		}
+		if !tt.ok {
+			continue
+		}
+		for _, action := range k.actions() {
+			if action.name == tt.action && !reflect.DeepEqual(action.binding.Keys(), tt.want) {
+				t.Errorf("LoadKeys(%q, %v) binds %s to %q, want %q", tt.preset, tt.config, tt.action, action.binding.Keys(), tt.want)
+			}
+		}
+	}
+}
+
+func TestLoadKeysKeepsDefaults(t *testing.T) {
+	before := keys.Up.Keys()
+	if _, err := LoadKeys("vim", func(string) string { return "" }); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(keys.Up.Keys(), before) {
+		t.Errorf("LoadKeys changed the default bindings to %q", keys.Up.Keys())
+	}
+}
+
+func TestKeysLabel(t *testing.T) {
+	tests := []struct {
+		keys []string
+		want string
+	}{
+		{[]string{"up", "k"}, "↑/k"},
+		{[]string{"shift+left"}, "⇧←"},
+		{[]string{" ", "enter"}, "space/enter"},
+		{nil, ""},
+	}
+	for _, tt := range tests {
+		if got := KeysLabel(tt.keys); got != tt.want {
+			t.Errorf("KeysLabel(%q) = %q, want %q", tt.keys, got, tt.want)
+		}
+	}
+}
@@ -8,9 +8,20 @@ import (
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "notify" {
+		RunNotify(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && IsCLICommand(os.Args[1]) {
+		os.Exit(RunCLI(os.Args[1], os.Args[2:]))
+	}
 	Setup()
 	godotenv.Load()
 	style = SetStyles()
+	logFile, err := LogToConfigDir()
+	if err == nil {
+		defer logFile.Close()
+	}
 	p := tea.NewProgram(InitialModel())
 	if _, err := p.Run(); err != nil {
 		fmt.Printf("Alas, there's been an error: %v", err)
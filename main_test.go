@@ -0,0 +1,14 @@
+package main
+
+import (
+	"os"
+	"testing"
+)
+
+// Tests read and write times in UTC, whatever the zone of the machine.
+func TestMain(m *testing.M) {
+	if err := SetCalendarLocation("UTC"); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
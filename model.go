@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"strings"
@@ -19,22 +20,113 @@ type DateTime struct {
 	Date     string    `json:"date"`
 	TimeZone int       `json:"timeZone"`
 }
+type Person struct {
+	Email       string `json:"email"`
+	DisplayName string `json:"displayName"`
+	Self        bool   `json:"self"`
+}
+
+type Attendee struct {
+	Email          string `json:"email"`
+	DisplayName    string `json:"displayName"`
+	Organizer      bool   `json:"organizer"`
+	Self           bool   `json:"self"`
+	Optional       bool   `json:"optional"`
+	ResponseStatus string `json:"responseStatus"`
+	Comment        string `json:"comment"`
+}
+
+// EntryPoint is one way to join a conference: a video link, a phone number
+// or a SIP address.
+type EntryPoint struct {
+	Type  string `json:"type"`
+	URI   string `json:"uri"`
+	Label string `json:"label"`
+}
+
+type Conference struct {
+	Name        string       `json:"name"`
+	EntryPoints []EntryPoint `json:"entryPoints"`
+}
+
+type Source struct {
+	URL   string `json:"url"`
+	Title string `json:"title"`
+}
+
 type Event struct {
-	Id       string   `json:"event_id"`
-	Summary  string   `json:"summary"`
-	Start    DateTime `json:"start"`
-	End      DateTime `json:"end"`
-	Location string   `json:"location"`
+	Id         string   `json:"event_id"`
+	Etag       string   `json:"etag"`
+	Summary    string   `json:"summary"`
+	Start      DateTime `json:"start"`
+	End        DateTime `json:"end"`
+	Location   string   `json:"location"`
+	CalendarID string   `json:"calendarId"`
+	AllDay     bool     `json:"allDay"`
+	Recurrence []string `json:"recurrence"`
+	// RecurringEventId is the id of the series an occurrence belongs to
+	RecurringEventId string     `json:"recurringEventId"`
+	Pending          bool       `json:"pending"`
+	Description      string     `json:"description"`
+	HTMLLink         string     `json:"htmlLink"`
+	Organizer        Person     `json:"organizer"`
+	Attendees        []Attendee `json:"attendees"`
+	Conference       Conference `json:"conference"`
+	Source           Source     `json:"source"`
+
+	GuestsCanModify         bool `json:"guestsCanModify"`
+	GuestsCanInviteOthers   bool `json:"guestsCanInviteOthers"`
+	GuestsCanSeeOtherGuests bool `json:"guestsCanSeeOtherGuests"`
+	// AddConference asks the backend to create a Meet link on the next write
+	AddConference bool `json:"addConference,omitempty"`
+	// SendUpdates is who the next write notifies: all, externalOnly or none
+	SendUpdates string `json:"sendUpdates,omitempty"`
+	// Reminders replace the calendar's defaults when CustomReminders is set
+	Reminders       []Reminder `json:"reminders"`
+	CustomReminders bool       `json:"customReminders"`
 }
 
 type keyMap struct {
-	Up    key.Binding
-	Down  key.Binding
-	Left  key.Binding
-	Right key.Binding
-	Help  key.Binding
-	Flip  key.Binding
-	Quit  key.Binding
+	Up        key.Binding
+	Down      key.Binding
+	Left      key.Binding
+	Right     key.Binding
+	Open      key.Binding
+	Help      key.Binding
+	Flip      key.Binding
+	Calendars key.Binding
+	QuickAdd  key.Binding
+	Details   key.Binding
+	Rsvp      key.Binding
+	Edit      key.Binding
+	Join      key.Binding
+	Link      key.Binding
+	Source    key.Binding
+	View      key.Binding
+	DayWeek   key.Binding
+	NewAt     key.Binding
+	Cycle     key.Binding
+	Prev      key.Binding
+	Next      key.Binding
+	Today     key.Binding
+	Goto      key.Binding
+	FindTime  key.Binding
+	Undo      key.Binding
+	Redo      key.Binding
+	Search    key.Binding
+	NextMatch key.Binding
+	PrevMatch key.Binding
+	Clear     key.Binding
+	Mark      key.Binding
+	Batch     key.Binding
+	Earlier   key.Binding
+	Later     key.Binding
+	DayBefore key.Binding
+	DayAfter  key.Binding
+	Longer    key.Binding
+	Shorter   key.Binding
+	Duplicate key.Binding
+	Quit      key.Binding
 }
 
 var keys = keyMap{
@@ -58,6 +150,138 @@ var keys = keyMap{
 		key.WithKeys("right", "l"),
 		key.WithHelp("→/l", "move right"),
 	),
+	Open: key.NewBinding(
+		key.WithKeys(" ", "enter"),
+		key.WithHelp("enter", "edit"),
+	),
+	Calendars: key.NewBinding(
+		key.WithKeys("c"),
+		key.WithHelp("c", "calendars"),
+	),
+	QuickAdd: key.NewBinding(
+		key.WithKeys("a"),
+		key.WithHelp("a", "quick add"),
+	),
+	Details: key.NewBinding(
+		key.WithKeys("i"),
+		key.WithHelp("i", "details"),
+	),
+	Rsvp: key.NewBinding(
+		key.WithKeys("r"),
+		key.WithHelp("r", "rsvp"),
+	),
+	Edit: key.NewBinding(
+		key.WithKeys("e", "enter"),
+		key.WithHelp("e", "edit"),
+	),
+	Join: key.NewBinding(
+		key.WithKeys("m"),
+		key.WithHelp("m", "join call"),
+	),
+	Link: key.NewBinding(
+		key.WithKeys("o"),
+		key.WithHelp("o", "open in browser"),
+	),
+	Source: key.NewBinding(
+		key.WithKeys("s"),
+		key.WithHelp("s", "open source"),
+	),
+	View: key.NewBinding(
+		key.WithKeys("v"),
+		key.WithHelp("v", "switch view"),
+	),
+	DayWeek: key.NewBinding(
+		key.WithKeys("d"),
+		key.WithHelp("d", "day/week"),
+	),
+	NewAt: key.NewBinding(
+		key.WithKeys("n"),
+		key.WithHelp("n", "new at slot"),
+	),
+	Cycle: key.NewBinding(
+		key.WithKeys("tab"),
+		key.WithHelp("tab", "next overlapping"),
+	),
+	Prev: key.NewBinding(
+		key.WithKeys("["),
+		key.WithHelp("[", "previous days"),
+	),
+	Next: key.NewBinding(
+		key.WithKeys("]"),
+		key.WithHelp("]", "next days"),
+	),
+	Today: key.NewBinding(
+		key.WithKeys("t"),
+		key.WithHelp("t", "today"),
+	),
+	Goto: key.NewBinding(
+		key.WithKeys("g"),
+		key.WithHelp("g", "go to date"),
+	),
+	FindTime: key.NewBinding(
+		key.WithKeys("F"),
+		key.WithHelp("F", "find time"),
+	),
+	Undo: key.NewBinding(
+		key.WithKeys("u"),
+		key.WithHelp("u", "undo"),
+	),
+	Redo: key.NewBinding(
+		key.WithKeys("ctrl+r"),
+		key.WithHelp("ctrl+r", "redo"),
+	),
+	Search: key.NewBinding(
+		key.WithKeys("/"),
+		key.WithHelp("/", "search"),
+	),
+	NextMatch: key.NewBinding(
+		key.WithKeys("n"),
+		key.WithHelp("n", "next match"),
+	),
+	PrevMatch: key.NewBinding(
+		key.WithKeys("N"),
+		key.WithHelp("N", "previous match"),
+	),
+	Clear: key.NewBinding(
+		key.WithKeys("esc"),
+		key.WithHelp("esc", "clear search"),
+	),
+	Mark: key.NewBinding(
+		key.WithKeys("x"),
+		key.WithHelp("x", "mark"),
+	),
+	Batch: key.NewBinding(
+		key.WithKeys("b"),
+		key.WithHelp("b", "act on marked"),
+	),
+	Earlier: key.NewBinding(
+		key.WithKeys("K", "shift+up"),
+		key.WithHelp("K", "15m earlier"),
+	),
+	Later: key.NewBinding(
+		key.WithKeys("J", "shift+down"),
+		key.WithHelp("J", "15m later"),
+	),
+	DayBefore: key.NewBinding(
+		key.WithKeys("H", "shift+left"),
+		key.WithHelp("H", "day before"),
+	),
+	DayAfter: key.NewBinding(
+		key.WithKeys("L", "shift+right"),
+		key.WithHelp("L", "day after"),
+	),
+	Longer: key.NewBinding(
+		key.WithKeys("+", "="),
+		key.WithHelp("+", "end later"),
+	),
+	Shorter: key.NewBinding(
+		key.WithKeys("-"),
+		key.WithHelp("-", "end earlier"),
+	),
+	Duplicate: key.NewBinding(
+		key.WithKeys("D"),
+		key.WithHelp("D", "duplicate"),
+	),
 	Help: key.NewBinding(
 		key.WithKeys("f1"),
 		key.WithHelp("f1", "toggle help"),
@@ -75,66 +299,196 @@ type Point struct {
 
 type Model struct {
 	spinner      spinner.Model
+	loaded       []Event
 	events       []Event
 	keys         keyMap
 	help         help.Model
 	cursor       Point
 	point        Point
-	selected     map[Point]struct{}
+	marked       map[string]Event
 	eventMatrix  [][]Event
+	conflicting  map[string]bool
+	dayLayouts   [][]TimeGridBlock
+	allDayLanes  [][]BannerSpan
 	mode         int
 	inputs       []textinput.Model
 	focusIndex   int
 	showLocation bool
 	newEvent     bool
+	formEvent    Event
 	validFields  []bool
 	confirm      bool
-	config       apiConfig
+	backend      CalendarBackend
+	offline      bool
+	retrying     bool
+	pending      int
+	status       string
+	calendars    []Calendar
+	calendarIdx  int
+	formSeries   Event
+	scopeIdx     int
+	scopeOp      string
+	scopeEvent   Event
+	quickInput   textinput.Model
+	quickEvent   Event
+	quickErr     error
+	detailEvent  Event
+	rsvpEvent    Event
+	rsvpIdx      int
+	rsvpComment  textinput.Model
+	rsvpReturn   int
+	height       int
+	view         int
+	agendaIdx    int
+	agendaTop    int
+	slotDay      int
+	slot         int
+	slotPick     int
+	slotTop      int
+	dayView      bool
+	fetching     bool
+	gotoInput    textinput.Model
+	gotoErr      error
+	findInput    textinput.Model
+	findText     string
+	findQuery    FindTimeQuery
+	findSlots    []TimeRange
+	findIdx      int
+	findErr      error
+	finding      bool
+	filter       string
+	searchInput  textinput.Model
+	searchText   string
+	searchResult []Event
+	searchIdx    int
+	searchErr    error
+	searching    bool
+	jumpTo       Event
+	undoStack    []UndoEntry
+	redoStack    []UndoEntry
+	notice       string
+	batchStep    int
+	batchIdx     int
+	batchAction  BatchAction
+	batchInput   textinput.Model
+	batchErr     error
+	batchResults []BatchResult
+	moveBefore   Event
+	moveAfter    Event
+	moveSeq      int
+	dupEvent     Event
+	dupInput     textinput.Model
+	dupErr       error
 }
 
 type eventsLoadedMsg struct {
-	events []Event
-	err    error
+	// windowStart is the first day the events were loaded for
+	windowStart time.Time
+	events      []Event
+	pending     int
+	// discarded are the queued changes the calendar refused
+	discarded []string
+	timeZone  string
+	err       error
 }
 
+type eventSavedMsg struct {
+	op    string
+	event Event
+	// changes are the writes that were made, for undo
+	changes []Change
+	label   string
+	err     error
+}
+
+type retryMsg struct{}
+
+// retryInterval is how often go-home tries to reach the calendar again
+// while offline.
+const retryInterval = 30 * time.Second
+
 const (
 	Summary = iota
 	Date
 	StartTime
+	EndDate
 	EndTime
+	AllDay
 	Location
+	Repeat
+	Guests
+	Permissions
+	Video
+	Notify
+	Reminders
+	CalendarName
 	Id
 
 	calendar = iota
 	loading
 	forms
+	calendarPicker
+	recurrenceScope
+	quickAdd
+	detail
+	rsvp
+	gotoDate
+	findTime
+	search
+	batch
+	duplicate
+)
+
+// Layouts of the calendar mode, cycled with "v".
+const (
+	viewGrid = iota
+	viewAgenda
+	viewTimeGrid
+	viewCount
 )
 
 var apiConf apiConfig
 
+var calendarBackend CalendarBackend
+
 var style Styles
 
 func InitialModel() Model {
-	fmt.Println("Loading Events...")
-	events, err := GetEvents(apiConf)
-	if err != nil {
-		log.Fatalf("Failed to load events %e", err)
+	if zoned, ok := calendarBackend.(ZonedBackend); ok && !configuredTimeZone && zoned.TimeZone() != "" {
+		SetCalendarLocation(zoned.TimeZone())
+	}
+	mode := calendar
+	var events []Event
+	if cached, ok := calendarBackend.(CachedBackend); ok {
+		timeMin, timeMax := CurrentWindow()
+		events = VisibleEvents(cached.CachedEvents(timeMin, timeMax))
+	}
+	if events == nil {
+		mode = loading
+	}
+	var calendars []Calendar
+	if cached, ok := calendarBackend.(CachedBackend); ok {
+		calendars = cached.CachedCalendars()
 	}
 	s := spinner.New()
 	s.Spinner = spinner.Globe
-	s.Style = lipgloss.NewStyle().Foreground(lipgloss.Color("205"))
+	s.Style = style.spinnerStyle
 	eventMatrix := CreateEventMatrix(events)
+	today := TodayIndex()
 	m := Model{
 		spinner:     s,
 		events:      events,
-		selected:    make(map[Point]struct{}),
+		marked:      make(map[string]Event),
 		keys:        keys,
 		help:        help.New(),
 		eventMatrix: eventMatrix,
-		mode:        calendar,
-		inputs:      make([]textinput.Model, 8),
-		validFields: make([]bool, 8),
-		config:      apiConf,
+		mode:        mode,
+		inputs:      make([]textinput.Model, Id+3),
+		validFields: make([]bool, Id+3),
+		backend:     calendarBackend,
+		calendars:   calendars,
+		cursor:      Point{x: max(min(today, WindowDays()-1), 0)},
+		slotDay:     today,
 	}
 	var t textinput.Model
 	for i := range m.inputs {
@@ -148,29 +502,231 @@ func InitialModel() Model {
 	return m
 }
 
-func loadEventsCmd(config apiConfig) tea.Cmd {
+// loadEventsCmd fetches the days on screen. The window is read when the
+// command is made, so paging again before it finishes is noticed.
+func loadEventsCmd(backend CalendarBackend) tea.Cmd {
+	timeMin, timeMax := CurrentWindow()
 	return func() tea.Msg {
-		events, err := GetEvents(config)
-		return eventsLoadedMsg{events: events, err: err}
+		events, err := backend.ListEvents(timeMin, timeMax)
+		var pending int
+		var discarded []string
+		if queued, ok := backend.(QueuedBackend); ok {
+			pending = queued.PendingCount()
+			discarded = queued.Discarded()
+		}
+		var timeZone string
+		if zoned, ok := backend.(ZonedBackend); ok {
+			timeZone = zoned.TimeZone()
+		}
+		return eventsLoadedMsg{windowStart: timeMin, events: events, pending: pending, discarded: discarded, timeZone: timeZone, err: err}
 	}
 }
 
+// saveEventCmd writes event. before is the event as it was, which an
+// update needs to be undone.
+func saveEventCmd(backend CalendarBackend, op string, before Event, event Event) tea.Cmd {
+	return func() tea.Msg {
+		var err error
+		var changes []Change
+		result := event
+		switch op {
+		case opCreate:
+			result, err = backend.CreateEvent(event)
+			changes = []Change{{Op: opCreate, After: result}}
+		case opUpdate:
+			result, err = backend.UpdateEvent(event)
+			changes = []Change{{Op: opUpdate, Before: before, After: result}}
+		case opDelete:
+			err = backend.DeleteEvent(event)
+			changes = []Change{{Op: opDelete, Before: event}}
+		case opRespond:
+			result, err = backend.RespondToEvent(event)
+		}
+		if err != nil {
+			result, changes = event, nil
+		}
+		return eventSavedMsg{op: op, event: result, changes: changes, label: ChangeLabel(op, result), err: err}
+	}
+}
+
+func retryCmd() tea.Cmd {
+	return tea.Tick(retryInterval, func(time.Time) tea.Msg {
+		return retryMsg{}
+	})
+}
+
 func (m Model) Init() tea.Cmd {
-	return tea.Batch(tea.ClearScreen, textinput.Blink, m.spinner.Tick)
+	return tea.Batch(tea.ClearScreen, textinput.Blink, m.spinner.Tick, loadEventsCmd(m.backend), loadCalendarsCmd(m.backend))
 }
 
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.height = msg.Height
 	case eventsLoadedMsg:
+		var cmd tea.Cmd
+		m.pending = msg.pending
+		if len(msg.discarded) > 0 {
+			m.status = "Could not sync, discarded the " + strings.Join(msg.discarded, ", ")
+		}
+		if !configuredTimeZone && msg.timeZone != "" {
+			err := SetCalendarLocation(msg.timeZone)
+			if err != nil {
+				log.Printf("Unknown calendar time zone %q: %v\n", msg.timeZone, err)
+			}
+		}
+		if !msg.windowStart.Equal(WindowStart()) {
+			// The window moved, or the zone it is counted in changed,
+			// while these were loading
+			return m, loadEventsCmd(m.backend)
+		}
+		m.fetching = false
+		if errors.Is(msg.err, ErrOffline) {
+			m.offline = true
+			if !m.retrying {
+				m.retrying = true
+				cmd = retryCmd()
+			}
+		} else if msg.err != nil {
+			m.status = fmt.Sprintf("Failed to load events: %v", msg.err)
+			if m.mode == loading {
+				m.mode = calendar
+			}
+			return m, nil
+		} else {
+			m.offline = false
+		}
+		// Keep the cursor on the same event, or day, as the events move
+		// around it
+		event, day := m.selection()
+		m.setEvents(VisibleEvents(msg.events))
+		m.showMove()
+		m.refreshMarks()
+		m.clampCursor()
+		if m.jumpTo.Id != "" {
+			// Land on the search result the window was moved to
+			event, day = m.jumpTo, DateToIndex(EventDate(m.jumpTo))
+			m.jumpTo = Event{}
+			m.placeView(event, day)
+		} else if m.view == viewTimeGrid {
+			m.scrollTimeGrid()
+		} else {
+			m.placeView(event, day)
+		}
+		if m.mode == loading {
+			m.mode = calendar
+		}
+		return m, cmd
+	case retryMsg:
+		m.retrying = false
+		return m, tea.Batch(loadEventsCmd(m.backend), loadCalendarsCmd(m.backend))
+	case calendarsLoadedMsg:
 		if msg.err != nil {
-			return m, tea.Quit
+			m.status = fmt.Sprintf("Failed to load calendars: %v", msg.err)
+			return m, nil
 		}
-		m.events = msg.events
-		m.eventMatrix = CreateEventMatrix(m.events)
-		m.mode = calendar
+		m.calendars = msg.calendars
 		return m, nil
+	case calendarToggledMsg:
+		if msg.err != nil {
+			m.status = fmt.Sprintf("Failed to change calendar visibility: %v", msg.err)
+			return m, nil
+		}
+		for i := range m.calendars {
+			if m.calendars[i].Id == msg.calendarID {
+				m.calendars[i].Visible = msg.visible
+			}
+		}
+		return m, loadEventsCmd(m.backend)
+	case seriesLoadedMsg:
+		if msg.err != nil {
+			log.Printf("Failed to load recurring event for %s: %v\n", msg.instanceID, msg.err)
+			return m, nil
+		}
+		if m.mode == forms && m.formEvent.Id == msg.instanceID {
+			m.formSeries = msg.series
+			if m.inputs[Repeat].Value() == "" {
+				m.inputs[Repeat].SetValue(DescribeRecurrence(msg.series.Recurrence))
+			}
+		}
+		return m, nil
+	case eventSavedMsg:
+		if msg.err != nil {
+			m.status = fmt.Sprintf("Failed to %s %q: %v", msg.op, msg.event.Summary, msg.err)
+			if m.mode == loading {
+				m.mode = calendar
+			}
+			// Part of an edit to a series may have gone through
+			m.pushUndo(UndoEntry{Label: msg.label, Changes: msg.changes})
+			// Drop a change that was shown before it was saved
+			return m, loadEventsCmd(m.backend)
+		}
+		m.status = ""
+		m.pushUndo(UndoEntry{Label: msg.label, Changes: msg.changes})
+		if event, _ := m.selection(); msg.op == opCreate && IsLocalId(event.Id) {
+			// Follow a copy shown before it was saved to its real id
+			m.jumpTo = msg.event
+		}
+		return m, loadEventsCmd(m.backend)
+	case moveSaveMsg:
+		if msg.seq != m.moveSeq {
+			return m, nil
+		}
+		return m, m.flushMove()
+	case undoneMsg:
+		m.undone(msg)
+		if msg.err != nil && len(msg.applied.Changes) == 0 {
+			m.mode = calendar
+			return m, nil
+		}
+		return m, loadEventsCmd(m.backend)
 	}
 
+	if m.mode == calendar {
+		m.keys.Quit.SetEnabled(true)
+		m.keys.DayWeek.SetEnabled(m.view == viewTimeGrid)
+		m.keys.Cycle.SetEnabled(m.view == viewTimeGrid)
+		m.keys.NewAt.SetEnabled(m.view == viewTimeGrid && m.filter == "")
+		m.keys.NextMatch.SetEnabled(m.filter != "")
+		m.keys.PrevMatch.SetEnabled(m.filter != "")
+		m.keys.Clear.SetEnabled(m.filter != "" || len(m.marked) > 0)
+		m.keys.Batch.SetEnabled(len(m.marked) > 0)
+		if msg, ok := msg.(tea.KeyMsg); ok {
+			m.notice = ""
+			if cmd, ok := m.moveKey(msg); ok {
+				return m, cmd
+			}
+			if cmd := m.flushMove(); cmd != nil {
+				// Save a moved event before anything else can change it
+				next, nextCmd := m.Update(msg)
+				return next, tea.Sequence(cmd, nextCmd)
+			}
+			if cmd, ok := m.navigate(msg); ok {
+				return m, cmd
+			}
+		}
+	}
+	if m.mode == gotoDate {
+		return m.updateGotoDate(msg)
+	}
+	if m.mode == findTime {
+		return m.updateFindTime(msg)
+	}
+	if m.mode == search {
+		return m.updateSearch(msg)
+	}
+	if m.mode == batch {
+		return m.updateBatch(msg)
+	}
+	if m.mode == duplicate {
+		return m.updateDuplicate(msg)
+	}
+	if m.mode == calendar && m.view == viewAgenda {
+		return m.updateAgenda(msg)
+	}
+	if m.mode == calendar && m.view == viewTimeGrid {
+		return m.updateTimeGrid(msg)
+	}
 	if m.mode == calendar {
 		m.keys.Flip.SetEnabled(true)
 		switch msg := msg.(type) {
@@ -178,63 +734,80 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.help.Width = msg.Width
 
 		case tea.KeyMsg:
-			switch msg.String() {
-			case "ctrl+c", "q":
+			switch {
+			case msg.String() == "ctrl+c", key.Matches(msg, m.keys.Quit):
 				return m, tea.Quit
 
-			case "f1":
+			case key.Matches(msg, m.keys.Help):
 				m.help.ShowAll = !m.help.ShowAll
 
-			case "up", "k":
+			case key.Matches(msg, m.keys.Up):
 				if m.cursor.y > 0 {
 					m.cursor.y--
 				}
 
-			case "down", "j":
+			case key.Matches(msg, m.keys.Down):
 				if m.cursor.y < EventRowCount(m.events) && m.eventMatrix[m.cursor.y+1][m.cursor.x].Summary != "" {
 					m.cursor.y++
 				}
-			case "left", "h":
+			case key.Matches(msg, m.keys.Left):
 				if m.cursor.x > 0 && m.eventMatrix[m.cursor.y][m.cursor.x-1].Summary != "" {
 					m.cursor.x--
 				}
 
-			case "right", "l":
-				if m.cursor.x < 6 && m.eventMatrix[m.cursor.y][m.cursor.x+1].Summary != "" {
+			case key.Matches(msg, m.keys.Right):
+				if m.cursor.x < WindowDays()-1 && m.eventMatrix[m.cursor.y][m.cursor.x+1].Summary != "" {
 					m.cursor.x++
 				}
 
-			case "f":
+			case key.Matches(msg, m.keys.Flip):
 				m.showLocation = !m.showLocation
 
-			case " ", "enter":
-				_, ok := m.selected[Point{x: m.cursor.x, y: m.cursor.y}]
-				if ok {
-					delete(m.selected, Point{x: m.cursor.x, y: m.cursor.y})
-				} else {
-					m.mode = forms
-					m.focusIndex = 0
-					event := m.eventMatrix[m.cursor.y][m.cursor.x]
-					if event.Summary == "+" {
-						m.inputs[Summary].SetValue("")
-					} else {
-						m.inputs[Summary].SetValue(event.Summary)
-					}
-					if event.Start.Date == "" {
-						m.inputs[Date].SetValue(NewEventDate(m.cursor.x))
-					} else {
-						m.inputs[Date].SetValue(event.Start.Date)
+			case key.Matches(msg, m.keys.View):
+				m.switchView()
+				return m, nil
 
-					}
-					m.inputs[StartTime].SetValue(event.Start.DateTime.Format("15:04"))
-					m.inputs[EndTime].SetValue(event.End.DateTime.Format("15:04"))
-					m.inputs[Location].SetValue(event.Location)
-					m.inputs[Id].SetValue(event.Id)
-					m.selected[Point{x: m.cursor.x, y: m.cursor.y}] = struct{}{}
+			case key.Matches(msg, m.keys.QuickAdd):
+				return m, m.openQuickAdd()
+
+			case key.Matches(msg, m.keys.FindTime):
+				return m, m.openFindTime()
+
+			case key.Matches(msg, m.keys.Details):
+				m.openDetail(m.eventMatrix[m.cursor.y][m.cursor.x])
+				return m, nil
+
+			case key.Matches(msg, m.keys.Rsvp):
+				return m, m.openRsvp(m.eventMatrix[m.cursor.y][m.cursor.x])
+
+			case key.Matches(msg, m.keys.Calendars):
+				m.mode = calendarPicker
+				m.calendarIdx = 0
+				return m, nil
+
+			case key.Matches(msg, m.keys.Open):
+				m.openForm(m.eventMatrix[m.cursor.y][m.cursor.x], m.cursor.x)
+				if m.formEvent.RecurringEventId != "" {
+					return m, loadSeriesCmd(m.backend, m.formEvent)
 				}
 			}
 		}
 	}
+	if m.mode == calendarPicker {
+		return m.updateCalendarPicker(msg)
+	}
+	if m.mode == recurrenceScope {
+		return m.updateRecurrenceScope(msg)
+	}
+	if m.mode == quickAdd {
+		return m.updateQuickAdd(msg)
+	}
+	if m.mode == detail {
+		return m.updateDetail(msg)
+	}
+	if m.mode == rsvp {
+		return m.updateRsvp(msg)
+	}
 	if m.mode == loading {
 		var cmd tea.Cmd
 		m.spinner, cmd = m.spinner.Update(msg)
@@ -247,84 +820,68 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		} else {
 			m.keys.Quit.SetEnabled(true)
 		}
-		var newEvent Event
-		newEvent.Summary = "+"
-		if m.eventMatrix[m.cursor.y][m.cursor.y] == newEvent {
-			m.newEvent = true
-		}
 		switch msg := msg.(type) {
 		case tea.KeyMsg:
-			switch msg.String() {
+			switch s := msg.String(); {
 
-			case "q":
+			case key.Matches(msg, m.keys.Quit):
 				if m.focusIndex < len(m.inputs)-2 {
 					break
 				} else {
 					return m, tea.Quit
 				}
-			case "f1":
+			case key.Matches(msg, m.keys.Help):
 				m.help.ShowAll = !m.help.ShowAll
-			case "ctrl+c":
+			case s == "ctrl+c":
 				return m, tea.Quit
-			case "tab", "shift+tab", "enter", "up", "down":
-				s := msg.String()
+			case s == "tab", s == "shift+tab", s == "enter", s == "up", s == "down":
 				if s == "enter" && m.focusIndex == len(m.inputs)-2 {
-					if FormsValidation(m.inputs, &m.validFields) {
+					if FormsValidation(m.inputs, &m.validFields, m.calendars) {
 						return m, nil
 					}
-					var err error
-					var currentEvent Event
-					currentEvent.Id = m.inputs[Id].Value()
-					currentEvent.Start.Date = m.inputs[Date].Value()
-
-					formatedStartTime := fmt.Sprintf("%sT%s:00-06:00", m.inputs[Date].Value(), m.inputs[StartTime].Value())
-					currentEvent.Start.DateTime, err = time.Parse(time.RFC3339, formatedStartTime)
-					if err != nil {
-						log.Fatalf("Failed to parse start time %e", err)
-					}
-					formatedEndTime := fmt.Sprintf("%sT%s:00-06:00", m.inputs[Date].Value(), m.inputs[EndTime].Value())
-					currentEvent.End.DateTime, err = time.Parse(time.RFC3339, formatedEndTime)
-					if err != nil {
-						log.Fatalf("Failed to parse end time %e", err)
+					currentEvent, ok := m.eventFromForm()
+					if !ok {
+						return m, nil
 					}
 
-					currentEvent.Summary = m.inputs[Summary].Value()
-					currentEvent.Location = m.inputs[Location].Value()
-
+					op := opUpdate
 					if m.newEvent == true {
-						err = PostEvent(currentEvent, m.config)
-					} else {
-						err = UpdateEvent(currentEvent, m.config)
+						op = opCreate
 					}
-					if err != nil {
-						log.Fatalf("Failed to update Event %e", err)
+					if !m.newEvent && m.formEvent.RecurringEventId != "" {
+						m.askScope(opUpdate, currentEvent)
+						return m, nil
 					}
 					m.newEvent = false
-					delete(m.selected, Point{x: m.cursor.x, y: m.cursor.y})
 					m.mode = loading
-					return m, loadEventsCmd(m.config)
+					return m, saveEventCmd(m.backend, op, m.formEvent, currentEvent)
 				} else if s == "enter" && m.focusIndex == len(m.inputs)-1 {
 					for i := range m.validFields {
 						m.validFields[i] = true
 					}
 					m.newEvent = false
 					m.confirm = false
-					delete(m.selected, Point{x: m.cursor.x, y: m.cursor.y})
 					m.mode = calendar
 				} else if s == "enter" && m.focusIndex == len(m.inputs) {
 					if !m.confirm {
 						m.confirm = true
+					} else if m.formEvent.RecurringEventId != "" {
+						m.confirm = false
+						deleted := m.formEvent
+						deleted.SendUpdates, _ = ParseSendUpdates(m.inputs[Notify].Value())
+						m.askScope(opDelete, deleted)
+						return m, nil
 					} else {
-						DeleteEvent(m.eventMatrix[m.cursor.y][m.cursor.x], m.config)
+						deleted := m.formEvent
+						deleted.SendUpdates, _ = ParseSendUpdates(m.inputs[Notify].Value())
 						m.cursor.y -= 1
 						for i := range m.validFields {
 							m.validFields[i] = true
 						}
 						m.newEvent = false
 						m.confirm = false
-						delete(m.selected, Point{x: m.cursor.x, y: m.cursor.y})
 						m.mode = loading
-						return m, loadEventsCmd(m.config)
+						return m, saveEventCmd(m.backend, opDelete, deleted, deleted)
 					}
 				}
 
@@ -374,6 +931,67 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 }
 
+// clampCursor keeps the cursor on a card after the matrix is rebuilt.
+func (m *Model) clampCursor() {
+	if m.cursor.y > len(m.eventMatrix)-1 {
+		m.cursor.y = len(m.eventMatrix) - 1
+	}
+	for m.cursor.y > 0 && m.eventMatrix[m.cursor.y][m.cursor.x].Summary == "" {
+		m.cursor.y--
+	}
+}
+
+// selection is the event under the cursor of the current view and the day
+// it is on, the "+" card of the day when the cursor is not on an event.
+func (m Model) selection() (Event, int) {
+	switch m.view {
+	case viewAgenda:
+		item := m.agendaItem()
+		return item.Event, item.Day
+	case viewTimeGrid:
+		event, _ := m.eventAtSlot()
+		return event, m.slotDay
+	}
+	if m.cursor.y < 0 || m.cursor.y >= len(m.eventMatrix) || m.cursor.x >= len(m.eventMatrix[m.cursor.y]) {
+		return Event{}, m.cursor.x
+	}
+	return m.eventMatrix[m.cursor.y][m.cursor.x], m.cursor.x
+}
+
+// switchView moves to the next view, keeping the cursor on the same event
+// or day.
+func (m *Model) switchView() {
+	event, day := m.selection()
+	m.view = (m.view + 1) % viewCount
+	m.placeView(event, day)
+}
+
+// placeView puts the cursor of the current view on event, or on day when
+// the view does not show the event.
+func (m *Model) placeView(event Event, day int) {
+	switch m.view {
+	case viewAgenda:
+		m.placeAgenda(event, day)
+	case viewTimeGrid:
+		m.placeTimeGrid(event, day)
+	default:
+		m.placeGrid(event, day)
+	}
+}
+
+// placeGrid puts the grid cursor on event, or on the "+" card of day.
+func (m *Model) placeGrid(event Event, day int) {
+	m.cursor = Point{x: max(min(day, WindowDays()-1), 0), y: 0}
+	for y, row := range m.eventMatrix {
+		for x, card := range row {
+			if event.Id != "" && card.Id == event.Id {
+				m.cursor = Point{x: x, y: y}
+				return
+			}
+		}
+	}
+}
+
 func (m *Model) updateInputs(msg tea.Msg) tea.Cmd {
 	cmds := make([]tea.Cmd, len(m.inputs))
 	for i := range m.inputs {
@@ -386,7 +1004,10 @@ func (m Model) View() string {
 	var s string
 	switch m.mode {
 	case forms:
-		labels := []string{"Event:", "Date:", "Start Time:", "End Time:", "Location:", "Id: "}
+		labels := []string{"Event:", "Date:", "Start Time:", "End Date:", "End Time:", "All Day (y/n):", "Location:", "Repeat:", "Guests (emails):", "Guests can (modify,invite,see):", "Video call (y/n):", "Notify guests (all/external/none):", "Reminders:", "Calendar:", "Id: "}
+		if cal, ok := FindCalendar(m.calendars, m.inputs[CalendarName].Value()); ok {
+			labels[Reminders] = fmt.Sprintf("Reminders (calendar default: %s):", DescribeReminders(cal.DefaultReminders))
+		}
 		for i := range labels {
 			if !m.validFields[i] {
 				s += style.errorStyle.Render(labels[i] + " Invalid field")
@@ -420,6 +1041,9 @@ func (m Model) View() string {
 
 		}
 		var b strings.Builder
+		if conflicts := m.conflictsView(); conflicts != "" {
+			fmt.Fprintf(&b, "\n\n%s", strings.TrimSuffix(conflicts, "\n"))
+		}
 		fmt.Fprintf(&b, "\n\n%s %s %s \n\n", *submitButton, *cancelButton, *deleteButton)
 		s += b.String()
 		if m.confirm {
@@ -427,20 +1051,52 @@ func (m Model) View() string {
 		}
 	case loading:
 		s += fmt.Sprintf("Loading %s", m.spinner.View())
-	case calendar:
-		s += "\n"
+	case calendarPicker:
+		s += m.calendarPickerView()
+	case recurrenceScope:
+		s += m.recurrenceScopeView()
+	case quickAdd:
+		s += m.quickAddView()
+	case detail:
+		s += m.detailView()
+	case rsvp:
+		s += m.rsvpView()
+	case gotoDate:
+		s += m.gotoDateView()
+	case findTime:
+		s += m.findTimeView()
+	case batch:
+		s += m.batchView()
+	case duplicate:
+		s += m.duplicateView()
+	case calendar, search:
+		s += m.windowTitleView() + "\n"
+		if m.view == viewAgenda {
+			s += m.agendaView()
+			break
+		}
+		if m.view == viewTimeGrid {
+			s += m.timeGridView()
+			break
+		}
 
-		styledDays := GetDaysStartingToday()
-		dates := GetDateStartingToday()
+		styledDays := GetWindowDays()
+		dates := GetWindowDates()
 		for i := range styledDays {
-			styledDays[i] = style.dayStyle.Render(fmt.Sprint(styledDays[i], "-", dates[i]))
+			dayStyle := style.dayStyle
+			if i == TodayIndex() {
+				dayStyle = style.todayStyle
+			}
+			styledDays[i] = dayStyle.Render(fmt.Sprint(styledDays[i], "-", dates[i]))
 		}
 		s += lipgloss.JoinHorizontal(
 			lipgloss.Top,
 			styledDays...,
 		)
 		s += "\n"
+		s += m.allDayBannerView()
 
+		conflicting := m.conflicting
 		for i, rows := range m.eventMatrix {
 			rowEventsTitle := []string{}
 			for j, event := range rows {
@@ -453,11 +1109,11 @@ func (m Model) View() string {
 						rowEventsTitle = append(rowEventsTitle, style.hoverAddEventStyle.Render(event.Summary))
 					default:
 						if !m.showLocation {
-							start := event.Start.DateTime.Format("15:04")
-							end := event.End.DateTime.Format("15:04")
-							rowEventsTitle = append(rowEventsTitle, style.hoverCardEventStyle.Render(event.Summary+"\n"+start+"-"+end))
+							start := event.Start.DateTime.In(CalendarLocation()).Format("15:04")
+							end := event.End.DateTime.In(CalendarLocation()).Format("15:04")
+							rowEventsTitle = append(rowEventsTitle, rsvpCardStyle(style.hoverCardEventStyle, event).Render(m.markedSummary(ConflictSummary(event, conflicting), event)+"\n"+start+"-"+end+"\n"+m.calendarName(event)))
 						} else {
-							rowEventsTitle = append(rowEventsTitle, style.hoverCardEventStyle.Render(event.Location))
+							rowEventsTitle = append(rowEventsTitle, rsvpCardStyle(style.hoverCardEventStyle, event).Render(event.Location))
 						}
 					}
 					continue
@@ -468,7 +1124,11 @@ func (m Model) View() string {
 					case "+":
 						rowEventsTitle = append(rowEventsTitle, style.addEventStyle.Render((event.Summary)))
 					default:
-						rowEventsTitle = append(rowEventsTitle, style.cardEventStyle.Render(Truncate(event.Summary, 25, true)))
+						cardStyle := rsvpCardStyle(style.cardEventStyle, event)
+						if color := m.calendarColor(event); color != "" {
+							cardStyle = cardStyle.BorderForeground(lipgloss.Color(color))
+						}
+						rowEventsTitle = append(rowEventsTitle, cardStyle.Render(Truncate(m.markedSummary(ConflictSummary(event, conflicting), event), 25, true)))
 					}
 
 				}
@@ -482,15 +1142,66 @@ func (m Model) View() string {
 		}
 	}
 	s += "\n"
+	s += m.statusLine()
 	s += m.help.View(m.keys)
 	return s
 }
+
+// allDayBannerView draws all-day events as bars across the day columns
+// they cover.
+func (m Model) allDayBannerView() string {
+	var s string
+	columnWidth := style.dayStyle.GetWidth()
+	for _, lane := range m.allDayLanes {
+		var row []string
+		column := 0
+		for _, span := range lane {
+			if span.Start > column {
+				row = append(row, strings.Repeat(" ", (span.Start-column)*columnWidth))
+			}
+			width := (span.End-span.Start+1)*columnWidth - 1
+			bar := style.allDayEventStyle
+			if color := m.calendarColor(span.Event); color != "" {
+				bar = bar.Background(lipgloss.Color(color))
+			}
+			row = append(row, bar.Width(width).MaxHeight(1).Render(Truncate(m.markedSummary(CardSummary(span.Event), span.Event), max(width, 0), false))+" ")
+			column = span.End + 1
+		}
+		s += lipgloss.JoinHorizontal(lipgloss.Top, row...)
+		s += "\n"
+	}
+	return s
+}
+func (m Model) statusLine() string {
+	var s string
+	if m.mode == search {
+		s += m.searchView()
+	}
+	if m.offline {
+		s += style.warningStyle.Render(fmt.Sprintf("Offline, %d change(s) waiting to sync", m.pending))
+		s += "\n"
+	} else if m.pending > 0 {
+		s += style.warningStyle.Render(fmt.Sprintf("Syncing %d change(s)", m.pending))
+		s += "\n"
+	}
+	if m.status != "" {
+		s += style.errorStyle.Render(m.status)
+		s += "\n"
+	}
+	if m.notice != "" {
+		s += style.focusedStyle.Render(m.notice)
+		s += "\n"
+	}
+	return s
+}
 func (k keyMap) ShortHelp() []key.Binding {
 	return []key.Binding{k.Help, k.Quit}
 }
 func (k keyMap) FullHelp() [][]key.Binding {
 	return [][]key.Binding{
-		{k.Up, k.Down, k.Left, k.Right, k.Flip},
-		{k.Help, k.Quit},
+		{k.Up, k.Down, k.Left, k.Right, k.Open, k.Flip, k.Calendars, k.QuickAdd, k.Details, k.Rsvp, k.View, k.DayWeek, k.Cycle, k.NewAt},
+		{k.Prev, k.Next, k.Today, k.Goto, k.FindTime, k.Search, k.NextMatch, k.PrevMatch, k.Clear},
+		{k.Earlier, k.Later, k.DayBefore, k.DayAfter, k.Longer, k.Shorter, k.Duplicate},
+		{k.Mark, k.Batch, k.Undo, k.Redo, k.Help, k.Quit},
 	}
 }
@@ -0,0 +1,449 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// notifyGrace is how long after an event starts a missed reminder is
+	// still worth showing, e.g. when the machine was asleep.
+	notifyGrace = 5 * time.Minute
+	// notifyRefresh is how often the daemon asks the backend for changes,
+	// between refreshes it works from the events it already has.
+	notifyRefresh = 5 * time.Minute
+	// tokenRefresh keeps the access token, which lasts an hour, fresh.
+	tokenRefresh = 30 * time.Minute
+	// firedRetention is how long fired alerts are remembered.
+	firedRetention = 48 * time.Hour
+)
+
+// Alert is one reminder of one event that is due.
+type Alert struct {
+	Key     string
+	Event   Event
+	Minutes int
+	Snoozed bool
+}
+
+// NotifyState is what the notifier remembers across restarts so the same
+// reminder is never shown twice.
+type NotifyState struct {
+	mu      sync.Mutex
+	path    string
+	Fired   map[string]time.Time `json:"fired"`
+	Snoozed map[string]Snooze    `json:"snoozed"`
+	// Last is the alerts shown most recently, the ones "notify snooze" acts on
+	Last []Snooze `json:"last"`
+}
+
+type Snooze struct {
+	Key   string    `json:"key"`
+	Event Event     `json:"event"`
+	Until time.Time `json:"until"`
+}
+
+func NotifyStatePath() (string, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "go-home", "notify.json"), nil
+}
+
+func LoadNotifyState() (*NotifyState, error) {
+	path, err := NotifyStatePath()
+	if err != nil {
+		return nil, err
+	}
+	state := &NotifyState{path: path}
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	if err == nil {
+		err = json.Unmarshal(data, state)
+		if err != nil {
+			log.Printf("Ignoring corrupt notify state %v\n", err)
+		}
+	}
+	if state.Fired == nil {
+		state.Fired = make(map[string]time.Time)
+	}
+	if state.Snoozed == nil {
+		state.Snoozed = make(map[string]Snooze)
+	}
+	return state, nil
+}
+
+func (s *NotifyState) Save() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, fired := range s.Fired {
+		if time.Since(fired) > firedRetention {
+			delete(s.Fired, key)
+		}
+	}
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(s.path), 0755)
+	if err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	err = os.WriteFile(tmp, data, 0644)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
+
+// Reload picks up snoozes written by "go-home notify snooze" from another
+// process.
+func (s *NotifyState) Reload() {
+	loaded, err := LoadNotifyState()
+	if err != nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key, snooze := range loaded.Snoozed {
+		if _, ok := s.Snoozed[key]; !ok {
+			s.Snoozed[key] = snooze
+		}
+	}
+}
+
+func (s *NotifyState) MarkFired(alerts []Alert, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Last = nil
+	for _, alert := range alerts {
+		s.Fired[alert.Key] = now
+		delete(s.Snoozed, alert.Key)
+		s.Last = append(s.Last, Snooze{Key: alert.Key, Event: alert.Event})
+	}
+}
+
+func (s *NotifyState) Snooze(key string, event Event, until time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Snoozed[key] = Snooze{Key: key, Event: event, Until: until}
+}
+
+// alertKey identifies one reminder of one occurrence, moving the event
+// makes its reminders fire again.
+func alertKey(event Event, minutes int) string {
+	return fmt.Sprintf("%s@%d-%d", event.Id, event.Start.DateTime.Unix(), minutes)
+}
+
+// PopupReminders is the minutes before event a desktop notification is
+// due, from the event's own reminders or its calendar's defaults.
+func PopupReminders(event Event, calendars []Calendar) []int {
+	reminders := event.Reminders
+	if !event.CustomReminders {
+		if cal, ok := FindCalendar(calendars, event.CalendarID); ok {
+			reminders = cal.DefaultReminders
+		}
+	}
+	var minutes []int
+	for _, reminder := range reminders {
+		if reminder.Method == "popup" {
+			minutes = append(minutes, reminder.Minutes)
+		}
+	}
+	return minutes
+}
+
+// DueAlerts is every reminder that should be shown at now and has not been
+// yet, including snoozed ones whose snooze is over.
+func (s *NotifyState) DueAlerts(events []Event, calendars []Calendar, now time.Time) []Alert {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var alerts []Alert
+	for _, event := range events {
+		if SelfResponse(event) == "declined" {
+			continue
+		}
+		for _, minutes := range PopupReminders(event, calendars) {
+			key := alertKey(event, minutes)
+			if _, ok := s.Fired[key]; ok {
+				continue
+			}
+			fireAt := event.Start.DateTime.Add(-time.Duration(minutes) * time.Minute)
+			if !fireAt.After(now) && now.Before(event.Start.DateTime.Add(notifyGrace)) {
+				alerts = append(alerts, Alert{Key: key, Event: event, Minutes: minutes})
+			}
+		}
+	}
+	for key, snooze := range s.Snoozed {
+		if !snooze.Until.After(now) {
+			alerts = append(alerts, Alert{Key: key, Event: snooze.Event, Snoozed: true})
+		}
+	}
+	return alerts
+}
+
+func alertText(alert Alert, now time.Time) (string, string) {
+	event := alert.Event
+	title := event.Summary
+	until := event.Start.DateTime.Sub(now).Round(time.Minute)
+	switch {
+	case event.AllDay:
+		title += " today"
+		if DaysBetween(now.In(CalendarLocation()), event.Start.DateTime.In(CalendarLocation())) > 0 {
+			title = event.Summary + " " + event.Start.DateTime.In(CalendarLocation()).Format("Mon Jan 2")
+		}
+	case until > 0:
+		title += " in " + FormatMinutes(int(until.Minutes()))
+	default:
+		title += " now"
+	}
+	if alert.Snoozed {
+		title = "Snoozed: " + title
+	}
+	body := EventWhen(event)
+	if event.Location != "" {
+		body += "\n" + event.Location
+	}
+	if link := ConferenceLink(event); link != "" {
+		body += "\n" + link
+	}
+	return title, body
+}
+
+// SendNotification shows a desktop notification through notify-send, or
+// the freedesktop D-Bus interface directly with gdbus when notify-send is
+// missing. When onSnooze is set the notification gets a snooze button,
+// which needs a notify-send new enough to support actions.
+func SendNotification(title string, body string, onSnooze func()) error {
+	if path, err := exec.LookPath("notify-send"); err == nil {
+		args := []string{"--app-name=go-home", "--icon=x-office-calendar", title, body}
+		if onSnooze != nil {
+			cmd := exec.Command(path, append([]string{"--wait", "--action=snooze=Snooze " + FormatMinutes(int(snoozeFor().Minutes()))}, args...)...)
+			out, err := cmd.Output()
+			if err == nil {
+				if strings.TrimSpace(string(out)) == "snooze" {
+					onSnooze()
+				}
+				return nil
+			}
+			// Older notify-send without --action, show it without the button
+		}
+		return exec.Command(path, args...).Run()
+	}
+	if path, err := exec.LookPath("gdbus"); err == nil {
+		return exec.Command(path, "call", "--session",
+			"--dest", "org.freedesktop.Notifications",
+			"--object-path", "/org/freedesktop/Notifications",
+			"--method", "org.freedesktop.Notifications.Notify",
+			"go-home", "0", "x-office-calendar", title, body, "[]", "{}", "-1",
+		).Run()
+	}
+	return fmt.Errorf("neither notify-send nor gdbus is installed")
+}
+
+// snoozeFor is how long snooze delays an alert, set with NOTIFY_SNOOZE.
+func snoozeFor() time.Duration {
+	if value := os.Getenv("NOTIFY_SNOOZE"); value != "" {
+		if d, err := time.ParseDuration(value); err == nil && d > 0 {
+			return d
+		}
+		log.Printf("Invalid NOTIFY_SNOOZE %q, using 10m\n", value)
+	}
+	return 10 * time.Minute
+}
+
+// RunNotify is "go-home notify": it watches the calendar and shows a
+// desktop notification when a reminder is due.
+func RunNotify(args []string) {
+	fs := flag.NewFlagSet("notify", flag.ExitOnError)
+	demo := fs.Bool("d", false, "Watch the in-memory demo calendar")
+	once := fs.Bool("once", false, "Check once and exit instead of running as a daemon")
+	interval := fs.Duration("interval", time.Minute, "How often to check for due reminders")
+	install := fs.Bool("install", false, "Install a systemd user service that runs the notifier")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: go-home notify [flags]\n       go-home notify snooze [duration]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	LoadConfig(*demo)
+	if *install {
+		err := InstallNotifyService()
+		if err != nil {
+			log.Fatalf("Failed to install service %v", err)
+		}
+		return
+	}
+	state, err := LoadNotifyState()
+	if err != nil {
+		log.Fatalf("Failed to load notify state %v", err)
+	}
+	if fs.Arg(0) == "snooze" {
+		snoozeLast(state, fs.Arg(1))
+		return
+	}
+
+	var backend CalendarBackend
+	if *demo {
+		backend = NewMemoryBackend(DemoCalendars(), DemoEvents())
+	} else {
+		// The TUI owns the offline journal, the notifier only reads
+		backend = NewReadOnlyBackend()
+	}
+	if zoned, ok := backend.(ZonedBackend); ok && !configuredTimeZone && zoned.TimeZone() != "" {
+		SetCalendarLocation(zoned.TimeZone())
+	}
+
+	var events []Event
+	var calendars []Calendar
+	var lastFetch, lastRefresh time.Time
+	lastRefresh = time.Now()
+	for {
+		now := time.Now()
+		if now.Sub(lastRefresh) >= tokenRefresh {
+			err = backend.Refresh()
+			if err != nil {
+				log.Printf("Failed to refresh Oauth %v\n", err)
+			}
+			lastRefresh = now
+		}
+		if now.Sub(lastFetch) >= notifyRefresh || *once {
+			events, calendars = fetchForNotify(backend, events, calendars)
+			lastFetch = now
+		}
+
+		state.Reload()
+		alerts := state.DueAlerts(events, calendars, now)
+		for _, alert := range alerts {
+			title, body := alertText(alert, now)
+			go func() {
+				err := SendNotification(title, body, func() {
+					state.Snooze(alert.Key, alert.Event, time.Now().Add(snoozeFor()))
+					if err := state.Save(); err != nil {
+						log.Printf("Failed to save notify state %v\n", err)
+					}
+				})
+				if err != nil {
+					log.Printf("Failed to show notification for %q: %v\n", alert.Event.Summary, err)
+				}
+			}()
+		}
+		if len(alerts) > 0 {
+			state.MarkFired(alerts, now)
+			err = state.Save()
+			if err != nil {
+				log.Printf("Failed to save notify state %v\n", err)
+			}
+		}
+
+		if *once {
+			// Give notify-send a moment before the process exits
+			time.Sleep(time.Second)
+			return
+		}
+		time.Sleep(*interval)
+	}
+}
+
+// fetchForNotify loads the coming week, keeping what we had when the
+// backend cannot be reached.
+func fetchForNotify(backend CalendarBackend, events []Event, calendars []Calendar) ([]Event, []Calendar) {
+	// Reminders fire before the event, so look back far enough to still
+	// catch events that are about to start
+	timeMin := Now().Add(-notifyGrace)
+	timeMax := Now().AddDate(0, 0, 7)
+	fetched, err := backend.ListEvents(timeMin, timeMax)
+	if err != nil && !errors.Is(err, ErrOffline) {
+		log.Printf("Failed to load events %v\n", err)
+	}
+	if fetched != nil {
+		events = fetched
+	}
+	fetchedCalendars, err := backend.ListCalendars()
+	if err != nil {
+		log.Printf("Failed to load calendars %v\n", err)
+	}
+	if fetchedCalendars != nil {
+		calendars = fetchedCalendars
+	}
+	return events, calendars
+}
+
+func snoozeLast(state *NotifyState, value string) {
+	duration := snoozeFor()
+	if value != "" {
+		d, err := time.ParseDuration(value)
+		if err != nil || d <= 0 {
+			fmt.Fprintf(os.Stderr, "Invalid duration %q, use something like 10m\n", value)
+			os.Exit(2)
+		}
+		duration = d
+	}
+	if len(state.Last) == 0 {
+		fmt.Println("Nothing to snooze")
+		return
+	}
+	until := time.Now().Add(duration)
+	for _, last := range state.Last {
+		state.Snooze(last.Key, last.Event, until)
+		fmt.Printf("Snoozed %q until %s\n", last.Event.Summary, until.In(CalendarLocation()).Format("15:04"))
+	}
+	state.Last = nil
+	err := state.Save()
+	if err != nil {
+		log.Fatalf("Failed to save notify state %v", err)
+	}
+}
+
+const notifyService = `[Unit]
+Description=go-home calendar notifications
+After=graphical-session.target
+PartOf=graphical-session.target
+
+[Service]
+ExecStart=%s notify
+Restart=on-failure
+RestartSec=30
+
+[Install]
+WantedBy=graphical-session.target
+`
+
+// InstallNotifyService writes a systemd user unit that runs this binary's
+// notifier with the desktop session.
+func InstallNotifyService() error {
+	executable, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(configDir, "systemd", "user", "go-home-notify.service")
+	err = os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return err
+	}
+	err = os.WriteFile(path, []byte(fmt.Sprintf(notifyService, executable)), 0644)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Wrote %s\nStart it with: systemctl --user daemon-reload && systemctl --user enable --now go-home-notify\n", path)
+	return nil
+}
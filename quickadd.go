@@ -0,0 +1,313 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultDuration is how long quick-add events last when no end or
+// duration is given.
+const defaultDuration = time.Hour
+
+var (
+	clockPattern    = regexp.MustCompile(`^(\d{1,2})(?::(\d{2}))?(am|pm|a|p)?$`)
+	durationPattern = regexp.MustCompile(`^(\d+(?:\.\d+)?)(d|day|days|h|hr|hrs|hour|hours|m|min|mins|minute|minutes)?$`)
+)
+
+// clock is a time of day as typed, meridiem is "", "am" or "pm".
+type clock struct {
+	hour     int
+	minute   int
+	meridiem string
+}
+
+func parseClock(s string) (clock, bool) {
+	match := clockPattern.FindStringSubmatch(strings.ToLower(s))
+	if match == nil {
+		return clock{}, false
+	}
+	c := clock{}
+	c.hour, _ = strconv.Atoi(match[1])
+	if match[2] != "" {
+		c.minute, _ = strconv.Atoi(match[2])
+	}
+	switch match[3] {
+	case "am", "a":
+		c.meridiem = "am"
+	case "pm", "p":
+		c.meridiem = "pm"
+	}
+	if c.minute > 59 || c.hour > 24 || (c.meridiem != "" && (c.hour < 1 || c.hour > 12)) {
+		return clock{}, false
+	}
+	return c, true
+}
+
+// minutes is the clock as minutes after midnight, reading it with meridiem
+// when the clock has none of its own.
+func (c clock) minutes(meridiem string) int {
+	if c.meridiem != "" {
+		meridiem = c.meridiem
+	}
+	hour := c.hour
+	switch meridiem {
+	case "am":
+		if hour == 12 {
+			hour = 0
+		}
+	case "pm":
+		if hour != 12 {
+			hour += 12
+		}
+	}
+	return hour*60 + c.minute
+}
+
+// parseRange reads "9:30-9:45", "12-1pm" or "9am-5pm". A start without am or
+// pm borrows the end's, unless that would put the start after the end.
+func parseRange(s string) (int, int, bool) {
+	parts := strings.Split(s, "-")
+	if len(parts) != 2 {
+		return 0, 0, false
+	}
+	start, ok := parseClock(parts[0])
+	if !ok {
+		return 0, 0, false
+	}
+	end, ok := parseClock(parts[1])
+	if !ok {
+		return 0, 0, false
+	}
+	endMinutes := end.minutes("")
+	startMinutes := start.minutes(end.meridiem)
+	if start.meridiem == "" && end.meridiem == "pm" && startMinutes >= endMinutes {
+		startMinutes = start.minutes("am")
+	}
+	return startMinutes, endMinutes, true
+}
+
+// parseDuration reads "1h", "90m", "1.5h", "1h30m", "2d" or a number followed by
+// a unit in the next token, like "45 min". It returns how many tokens it
+// used.
+func parseDuration(tokens []string) (time.Duration, int, bool) {
+	if len(tokens) == 0 {
+		return 0, 0, false
+	}
+	if d, err := time.ParseDuration(tokens[0]); err == nil && d > 0 {
+		return d, 1, true
+	}
+	match := durationPattern.FindStringSubmatch(tokens[0])
+	if match == nil {
+		return 0, 0, false
+	}
+	value, _ := strconv.ParseFloat(match[1], 64)
+	unit := match[2]
+	used := 1
+	if unit == "" {
+		if len(tokens) < 2 {
+			return 0, 0, false
+		}
+		unitMatch := durationPattern.FindStringSubmatch("0" + tokens[1])
+		if unitMatch == nil || unitMatch[2] == "" {
+			return 0, 0, false
+		}
+		unit = unitMatch[2]
+		used = 2
+	}
+	d := time.Duration(value * float64(time.Minute))
+	switch unit[0] {
+	case 'h':
+		d = time.Duration(value * float64(time.Hour))
+	case 'd':
+		d = time.Duration(value * float64(24*time.Hour))
+	}
+	if d <= 0 {
+		return 0, 0, false
+	}
+	return d, used, true
+}
+
+// parseDay reads a day relative to today: "today", "tomorrow", a weekday
+// within the coming week, "in N days" or an explicit date. It returns how
+// many tokens it used.
+func parseDay(tokens []string, today time.Time) (time.Time, int, bool) {
+	word := strings.ToLower(tokens[0])
+	switch word {
+	case "today", "tod":
+		return today, 1, true
+	case "tomorrow", "tmr", "tmrw":
+		return today.AddDate(0, 0, 1), 1, true
+	case "in":
+		if len(tokens) >= 3 {
+			n, err := strconv.Atoi(tokens[1])
+			unit := strings.ToLower(tokens[2])
+			if err == nil && n >= 0 && (unit == "day" || unit == "days") {
+				return today.AddDate(0, 0, n), 3, true
+			}
+		}
+		return time.Time{}, 0, false
+	case "next":
+		// "next fri" is the first friday after today, never today itself
+		if len(tokens) >= 2 {
+			if code, ok := weekdayCodes[strings.ToLower(tokens[1])]; ok {
+				offset := (int(codeWeekdays[code])-int(today.Weekday())+6)%7 + 1
+				return today.AddDate(0, 0, offset), 2, true
+			}
+		}
+		return time.Time{}, 0, false
+	}
+	if code, ok := weekdayCodes[word]; ok {
+		offset := (int(codeWeekdays[code]) - int(today.Weekday()) + 7) % 7
+		return today.AddDate(0, 0, offset), 1, true
+	}
+	if day, err := time.ParseInLocation("2006-01-02", word, today.Location()); err == nil {
+		return day, 1, true
+	}
+	for _, layout := range []string{"1/2", "01/02"} {
+		if day, err := time.ParseInLocation(layout, word, today.Location()); err == nil {
+			day = time.Date(today.Year(), day.Month(), day.Day(), 0, 0, 0, 0, today.Location())
+			if day.Before(today) {
+				day = day.AddDate(1, 0, 0)
+			}
+			return day, 1, true
+		}
+	}
+	return time.Time{}, 0, false
+}
+
+// ParseDay reads a whole value as a day, like "2026-01-05", "tomorrow",
+// "fri", "next mon" or "in 3 days".
+func ParseDay(value string) (time.Time, error) {
+	tokens := strings.Fields(value)
+	if len(tokens) > 0 {
+		if day, used, ok := parseDay(tokens, StartOfDay(Now())); ok && used == len(tokens) {
+			return day, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("%q is not a day like 2026-01-05, tomorrow or fri", value)
+}
+
+// ParseQuickAdd turns a line like "standup tomorrow 9:30-9:45 @ room 4" or
+// "lunch fri 12 for 1h" into an event. Words that are not a day, time,
+// duration or location make up the summary.
+func ParseQuickAdd(text string, now time.Time) (Event, error) {
+	var event Event
+	if at := strings.Index(text, "@"); at >= 0 {
+		event.Location = strings.TrimSpace(text[at+1:])
+		text = text[:at]
+	}
+
+	today := StartOfDay(now)
+	day := today
+	dayFound := false
+	afterDay := false
+	startMinutes, endMinutes := -1, -1
+	var duration time.Duration
+	var summary []string
+
+	tokens := strings.Fields(text)
+	for i := 0; i < len(tokens); i++ {
+		token := tokens[i]
+		lower := strings.ToLower(token)
+		prevIsDay := afterDay
+		afterDay = false
+
+		if !dayFound {
+			if d, used, ok := parseDay(tokens[i:], today); ok {
+				day = d
+				dayFound = true
+				afterDay = true
+				i += used - 1
+				continue
+			}
+		}
+		if lower == "all" && i+1 < len(tokens) && strings.ToLower(tokens[i+1]) == "day" {
+			event.AllDay = true
+			i++
+			continue
+		}
+		if lower == "allday" || lower == "all-day" {
+			event.AllDay = true
+			continue
+		}
+		if startMinutes < 0 {
+			// Join "9 - 10" and "9 to 10" into one range
+			if i+2 < len(tokens) && (tokens[i+1] == "-" || strings.ToLower(tokens[i+1]) == "to") {
+				if s, e, ok := parseRange(token + "-" + tokens[i+2]); ok {
+					startMinutes, endMinutes = s, e
+					i += 2
+					continue
+				}
+			}
+			if s, e, ok := parseRange(lower); ok {
+				startMinutes, endMinutes = s, e
+				continue
+			}
+			if lower == "at" && i+1 < len(tokens) {
+				if c, ok := parseClock(tokens[i+1]); ok {
+					startMinutes = c.minutes("")
+					i++
+					continue
+				}
+			}
+			// A bare number is only a time right after the day, so
+			// "lunch for 2 people" keeps its number
+			if c, ok := parseClock(lower); ok && (c.meridiem != "" || strings.Contains(lower, ":") || prevIsDay) {
+				startMinutes = c.minutes("")
+				continue
+			}
+		}
+		if lower == "for" && endMinutes < 0 && duration == 0 {
+			if d, used, ok := parseDuration(tokens[i+1:]); ok {
+				duration = d
+				i += used
+				continue
+			}
+		}
+		summary = append(summary, token)
+	}
+
+	event.Summary = strings.Join(summary, " ")
+	if event.Summary == "" {
+		return Event{}, fmt.Errorf("missing a title")
+	}
+
+	if event.AllDay {
+		if startMinutes >= 0 {
+			return Event{}, fmt.Errorf("all-day events cannot have a time")
+		}
+		event.Start.DateTime = day
+		days := 1
+		if duration >= 24*time.Hour {
+			days = int(duration / (24 * time.Hour))
+		}
+		event.End.DateTime = day.AddDate(0, 0, days)
+		return normalizeEvent(event), nil
+	}
+
+	if startMinutes < 0 {
+		return Event{}, fmt.Errorf("missing a start time, like 9:30 or 2pm")
+	}
+	event.Start.DateTime = atMinutes(day, startMinutes)
+	switch {
+	case endMinutes >= 0:
+		event.End.DateTime = atMinutes(day, endMinutes)
+		if !event.End.DateTime.After(event.Start.DateTime) {
+			return Event{}, fmt.Errorf("the event ends before it starts")
+		}
+	case duration > 0:
+		event.End.DateTime = event.Start.DateTime.Add(duration)
+	default:
+		event.End.DateTime = event.Start.DateTime.Add(defaultDuration)
+	}
+	return normalizeEvent(event), nil
+}
+
+// atMinutes is the wall clock time minutes after midnight on day, which
+// stays right on days that are not 24 hours long.
+func atMinutes(day time.Time, minutes int) time.Time {
+	return time.Date(day.Year(), day.Month(), day.Day(), minutes/60, minutes%60, 0, 0, day.Location())
+}
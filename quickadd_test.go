@@ -0,0 +1,93 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseQuickAdd(t *testing.T) {
+	// A Wednesday afternoon
+	now := time.Date(2026, 1, 7, 15, 0, 0, 0, time.UTC)
+	at := func(day int, hour int, minute int) time.Time {
+		return time.Date(2026, 1, day, hour, minute, 0, 0, time.UTC)
+	}
+	tests := []struct {
+		text     string
+		summary  string
+		location string
+		start    time.Time
+		end      time.Time
+		allDay   bool
+		ok       bool
+	}{
+		{"standup tomorrow 9:30-9:45 @ room 4", "standup", "room 4", at(8, 9, 30), at(8, 9, 45), false, true},
+		{"lunch fri 12 for 1h", "lunch", "", at(9, 12, 0), at(9, 13, 0), false, true},
+		{"dentist 2pm", "dentist", "", at(7, 14, 0), at(7, 15, 0), false, true},
+		{"call today at 9", "call", "", at(7, 9, 0), at(7, 10, 0), false, true},
+		{"review 12-1pm", "review", "", at(7, 12, 0), at(7, 13, 0), false, true},
+		{"review 9 to 10 next wed", "review", "", at(14, 9, 0), at(14, 10, 0), false, true},
+		{"workshop in 3 days 10am for 90 min", "workshop", "", at(10, 10, 0), at(10, 11, 30), false, true},
+		{"lunch for 2 people 1pm", "lunch for 2 people", "", at(7, 13, 0), at(7, 14, 0), false, true},
+		{"offsite 2026-02-02 all day for 2d", "offsite", "", time.Date(2026, 2, 2, 0, 0, 0, 0, time.UTC), time.Date(2026, 2, 4, 0, 0, 0, 0, time.UTC), true, true},
+		{"holiday 1/2 allday", "holiday", "", time.Date(2027, 1, 2, 0, 0, 0, 0, time.UTC), time.Date(2027, 1, 3, 0, 0, 0, 0, time.UTC), true, true},
+		{"tomorrow 9am", "", "", time.Time{}, time.Time{}, false, false},
+		{"standup tomorrow", "", "", time.Time{}, time.Time{}, false, false},
+		{"late 5pm-4pm", "", "", time.Time{}, time.Time{}, false, false},
+		{"trip all day 9am", "", "", time.Time{}, time.Time{}, false, false},
+	}
+	for _, tt := range tests {
+		event, err := ParseQuickAdd(tt.text, now)
+		if (err == nil) != tt.ok {
+			t.Errorf("ParseQuickAdd(%q) error %v, want ok %v", tt.text, err, tt.ok)
+			continue
+		}
+		if !tt.ok {
+			continue
+		}
+		if event.Summary != tt.summary || event.Location != tt.location || event.AllDay != tt.allDay {
+			t.Errorf("ParseQuickAdd(%q) = %q @ %q all day %v, want %q @ %q all day %v",
+				tt.text, event.Summary, event.Location, event.AllDay, tt.summary, tt.location, tt.allDay)
+		}
+		if !event.Start.DateTime.Equal(tt.start) || !event.End.DateTime.Equal(tt.end) {
+			t.Errorf("ParseQuickAdd(%q) runs %v to %v, want %v to %v",
+				tt.text, event.Start.DateTime, event.End.DateTime, tt.start, tt.end)
+		}
+	}
+}
+
+func TestParseDay(t *testing.T) {
+	today := StartOfDay(Now())
+	weekday := func(day time.Weekday, next bool) time.Time {
+		offset := (int(day) - int(today.Weekday()) + 7) % 7
+		if next && offset == 0 {
+			offset = 7
+		}
+		return today.AddDate(0, 0, offset)
+	}
+	tests := []struct {
+		value string
+		want  time.Time
+		ok    bool
+	}{
+		{"today", today, true},
+		{" tomorrow ", today.AddDate(0, 0, 1), true},
+		{"in 3 days", today.AddDate(0, 0, 3), true},
+		{"fri", weekday(time.Friday, false), true},
+		{"Next Mon", weekday(time.Monday, true), true},
+		{"2026-01-05", time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC), true},
+		{"", time.Time{}, false},
+		{"someday", time.Time{}, false},
+		{"tomorrow 9am", time.Time{}, false},
+		{"in 3 weeks", time.Time{}, false},
+	}
+	for _, tt := range tests {
+		got, err := ParseDay(tt.value)
+		if (err == nil) != tt.ok {
+			t.Errorf("ParseDay(%q) error %v, want ok %v", tt.value, err, tt.ok)
+			continue
+		}
+		if tt.ok && !got.Equal(tt.want) {
+			t.Errorf("ParseDay(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,483 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Which occurrences of a recurring event an edit or delete applies to.
+const (
+	scopeThis = iota
+	scopeFollowing
+	scopeAll
+)
+
+// maxOccurrences bounds expansion of rules without COUNT or UNTIL.
+const maxOccurrences = 1000
+
+// RRule is the subset of RFC 5545 recurrence rules go-home can create and
+// expand: FREQ, INTERVAL, BYDAY, BYMONTHDAY, COUNT and UNTIL. Monthly rules
+// may number their days, like 3TU for the third Tuesday or -1FR for the last
+// Friday.
+type RRule struct {
+	Freq       string
+	Interval   int
+	ByDay      []string
+	ByMonthDay []int
+	Count      int
+	Until      time.Time
+}
+
+var weekdayCodes = map[string]string{
+	"mon": "MO", "monday": "MO",
+	"tue": "TU", "tues": "TU", "tuesday": "TU",
+	"wed": "WE", "wednesday": "WE",
+	"thu": "TH", "thur": "TH", "thurs": "TH", "thursday": "TH",
+	"fri": "FR", "friday": "FR",
+	"sat": "SA", "saturday": "SA",
+	"sun": "SU", "sunday": "SU",
+}
+
+var codeWeekdays = map[string]time.Weekday{
+	"SU": time.Sunday, "MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday,
+	"TH": time.Thursday, "FR": time.Friday, "SA": time.Saturday,
+}
+
+var weekdayNames = map[string]string{
+	"MO": "mon", "TU": "tue", "WE": "wed", "TH": "thu", "FR": "fri", "SA": "sat", "SU": "sun",
+}
+
+var unitFreqs = map[string]string{
+	"day": "DAILY", "days": "DAILY",
+	"week": "WEEKLY", "weeks": "WEEKLY",
+	"month": "MONTHLY", "months": "MONTHLY",
+	"year": "YEARLY", "years": "YEARLY",
+}
+
+// ParseRRule reads a single "RRULE:" line.
+func ParseRRule(line string) (RRule, error) {
+	rule := RRule{Interval: 1}
+	body := strings.TrimPrefix(strings.TrimSpace(line), "RRULE:")
+	for _, part := range strings.Split(body, ";") {
+		name, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return rule, fmt.Errorf("malformed rule part %q", part)
+		}
+		switch strings.ToUpper(name) {
+		case "FREQ":
+			rule.Freq = strings.ToUpper(value)
+		case "INTERVAL":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return rule, fmt.Errorf("invalid interval %q", value)
+			}
+			rule.Interval = n
+		case "BYDAY":
+			for _, day := range strings.Split(strings.ToUpper(value), ",") {
+				if _, _, ok := splitByDay(day); !ok {
+					return rule, fmt.Errorf("unsupported BYDAY %q", day)
+				}
+				rule.ByDay = append(rule.ByDay, day)
+			}
+		case "BYMONTHDAY":
+			for _, day := range strings.Split(value, ",") {
+				n, err := strconv.Atoi(day)
+				if err != nil || n == 0 || n < -31 || n > 31 {
+					return rule, fmt.Errorf("invalid BYMONTHDAY %q", day)
+				}
+				rule.ByMonthDay = append(rule.ByMonthDay, n)
+			}
+		case "COUNT":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return rule, fmt.Errorf("invalid count %q", value)
+			}
+			rule.Count = n
+		case "UNTIL":
+			until, err := time.Parse("20060102T150405Z", value)
+			if err != nil {
+				until, err = time.ParseInLocation("20060102", value, CalendarLocation())
+				if err != nil {
+					return rule, fmt.Errorf("invalid until %q", value)
+				}
+				until = until.Add(24*time.Hour - time.Second)
+			}
+			rule.Until = until
+		case "WKST":
+		default:
+			return rule, fmt.Errorf("unsupported rule part %q", name)
+		}
+	}
+	switch rule.Freq {
+	case "DAILY", "WEEKLY", "MONTHLY", "YEARLY":
+	default:
+		return rule, fmt.Errorf("unsupported frequency %q", rule.Freq)
+	}
+	if rule.Freq != "MONTHLY" {
+		if len(rule.ByMonthDay) > 0 {
+			return rule, fmt.Errorf("BYMONTHDAY is only supported on monthly rules")
+		}
+		for _, day := range rule.ByDay {
+			if ordinal, _, _ := splitByDay(day); ordinal != 0 {
+				return rule, fmt.Errorf("BYDAY %q is only supported on monthly rules", day)
+			}
+		}
+	}
+	return rule, nil
+}
+
+// splitByDay reads a BYDAY value like MO, 3TU or -1FR into its ordinal, 0
+// for every such weekday, and the weekday.
+func splitByDay(day string) (int, time.Weekday, bool) {
+	if len(day) < 2 {
+		return 0, 0, false
+	}
+	weekday, ok := codeWeekdays[day[len(day)-2:]]
+	if !ok {
+		return 0, 0, false
+	}
+	if len(day) == 2 {
+		return 0, weekday, true
+	}
+	ordinal, err := strconv.Atoi(day[:len(day)-2])
+	if err != nil || ordinal == 0 || ordinal < -5 || ordinal > 5 {
+		return 0, 0, false
+	}
+	return ordinal, weekday, true
+}
+
+// monthDays lists the days of the month starting at first that a monthly
+// rule with BYDAY or BYMONTHDAY falls on. When it has both, a day must match
+// each.
+func (r RRule) monthDays(first time.Time) []time.Time {
+	length := first.AddDate(0, 1, -1).Day()
+	var days []time.Time
+	for n := 1; n <= length; n++ {
+		day := first.AddDate(0, 0, n-1)
+		matched := len(r.ByMonthDay) == 0
+		for _, monthDay := range r.ByMonthDay {
+			if monthDay == n || monthDay == n-length-1 {
+				matched = true
+			}
+		}
+		if !matched {
+			continue
+		}
+		matched = len(r.ByDay) == 0
+		for _, code := range r.ByDay {
+			ordinal, weekday, _ := splitByDay(code)
+			if weekday != day.Weekday() {
+				continue
+			}
+			if ordinal == 0 || ordinal == (n-1)/7+1 || ordinal == -((length-n)/7+1) {
+				matched = true
+			}
+		}
+		if matched {
+			days = append(days, day)
+		}
+	}
+	return days
+}
+
+// String renders the rule as an RRULE line. All-day events need UNTIL as a
+// plain date, timed events as a UTC timestamp.
+func (r RRule) String(allDay bool) string {
+	parts := []string{"FREQ=" + r.Freq}
+	if r.Interval > 1 {
+		parts = append(parts, fmt.Sprintf("INTERVAL=%d", r.Interval))
+	}
+	if len(r.ByDay) > 0 {
+		parts = append(parts, "BYDAY="+strings.Join(r.ByDay, ","))
+	}
+	if len(r.ByMonthDay) > 0 {
+		var days []string
+		for _, day := range r.ByMonthDay {
+			days = append(days, strconv.Itoa(day))
+		}
+		parts = append(parts, "BYMONTHDAY="+strings.Join(days, ","))
+	}
+	if r.Count > 0 {
+		parts = append(parts, fmt.Sprintf("COUNT=%d", r.Count))
+	}
+	if !r.Until.IsZero() {
+		if allDay {
+			parts = append(parts, "UNTIL="+r.Until.In(CalendarLocation()).Format("20060102"))
+		} else {
+			parts = append(parts, "UNTIL="+r.Until.UTC().Format("20060102T150405Z"))
+		}
+	}
+	return "RRULE:" + strings.Join(parts, ";")
+}
+
+// ParseRepeat turns the form's repeat text, like "weekdays", "every 2 weeks
+// on mon,wed until 2026-12-01" or "daily count 5", into a recurrence list.
+// An empty text means the event does not repeat.
+func ParseRepeat(spec string, allDay bool) ([]string, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" || strings.EqualFold(spec, "none") || strings.EqualFold(spec, "never") {
+		return nil, nil
+	}
+	if strings.HasPrefix(strings.ToUpper(spec), "RRULE:") {
+		_, err := ParseRRule(spec)
+		if err != nil {
+			return nil, err
+		}
+		return []string{spec}, nil
+	}
+
+	rule := RRule{Interval: 1}
+	tokens := strings.FieldsFunc(strings.ToLower(spec), func(r rune) bool {
+		return r == ' ' || r == ','
+	})
+	for i := 0; i < len(tokens); i++ {
+		token := tokens[i]
+		next := ""
+		if i+1 < len(tokens) {
+			next = tokens[i+1]
+		}
+		switch {
+		case token == "daily":
+			rule.Freq = "DAILY"
+		case token == "weekly":
+			rule.Freq = "WEEKLY"
+		case token == "monthly":
+			rule.Freq = "MONTHLY"
+		case token == "yearly" || token == "annually":
+			rule.Freq = "YEARLY"
+		case token == "weekdays":
+			rule.Freq = "WEEKLY"
+			rule.ByDay = []string{"MO", "TU", "WE", "TH", "FR"}
+		case token == "every" || token == "on" || token == "times":
+		case unitFreqs[token] != "":
+			rule.Freq = unitFreqs[token]
+		case weekdayCodes[token] != "":
+			if rule.Freq == "" {
+				rule.Freq = "WEEKLY"
+			}
+			rule.ByDay = append(rule.ByDay, weekdayCodes[token])
+		case token == "until":
+			until, err := time.ParseInLocation("2006-01-02", next, CalendarLocation())
+			if err != nil {
+				return nil, fmt.Errorf("until needs a date like 2006-01-02")
+			}
+			rule.Until = until.Add(24*time.Hour - time.Second)
+			i++
+		case token == "count" || token == "for":
+			n, err := strconv.Atoi(next)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("%s needs a number", token)
+			}
+			rule.Count = n
+			i++
+		default:
+			n, err := strconv.Atoi(token)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("unknown repeat %q", token)
+			}
+			if next == "times" {
+				rule.Count = n
+			} else {
+				rule.Interval = n
+			}
+		}
+	}
+	if rule.Freq == "" {
+		return nil, fmt.Errorf("repeat needs a frequency like daily or weekly")
+	}
+	if rule.Count > 0 && !rule.Until.IsZero() {
+		return nil, fmt.Errorf("use either until or count, not both")
+	}
+	return []string{rule.String(allDay)}, nil
+}
+
+// DescribeRecurrence is the inverse of ParseRepeat, used to fill the form.
+func DescribeRecurrence(recurrence []string) string {
+	for _, line := range recurrence {
+		if !strings.HasPrefix(line, "RRULE:") {
+			continue
+		}
+		rule, err := ParseRRule(line)
+		if err != nil || rule.Freq == "MONTHLY" && (len(rule.ByDay) > 0 || len(rule.ByMonthDay) > 0) {
+			// The form takes the rule as it is when it has no words for it
+			return line
+		}
+		var parts []string
+		switch {
+		case rule.Freq == "WEEKLY" && rule.Interval == 1 && strings.Join(rule.ByDay, ",") == "MO,TU,WE,TH,FR":
+			parts = append(parts, "weekdays")
+		case rule.Interval > 1:
+			units := map[string]string{"DAILY": "days", "WEEKLY": "weeks", "MONTHLY": "months", "YEARLY": "years"}
+			parts = append(parts, fmt.Sprintf("every %d %s", rule.Interval, units[rule.Freq]))
+		default:
+			parts = append(parts, strings.ToLower(rule.Freq))
+		}
+		if len(rule.ByDay) > 0 && parts[0] != "weekdays" {
+			var days []string
+			for _, day := range rule.ByDay {
+				days = append(days, weekdayNames[day])
+			}
+			parts = append(parts, "on "+strings.Join(days, ","))
+		}
+		if rule.Count > 0 {
+			parts = append(parts, fmt.Sprintf("count %d", rule.Count))
+		}
+		if !rule.Until.IsZero() {
+			parts = append(parts, "until "+rule.Until.In(CalendarLocation()).Format(time.DateOnly))
+		}
+		return strings.Join(parts, " ")
+	}
+	return ""
+}
+
+// ReplaceRule swaps the RRULE lines of recurrence for those of rules and
+// keeps the EXDATE and RDATE lines, which skip or add single occurrences.
+func ReplaceRule(recurrence []string, rules []string) []string {
+	var old, replaced []string
+	for _, line := range recurrence {
+		if strings.HasPrefix(line, "RRULE:") {
+			old = append(old, line)
+		} else {
+			replaced = append(replaced, line)
+		}
+	}
+	var added []string
+	for _, line := range rules {
+		if strings.HasPrefix(line, "RRULE:") {
+			added = append(added, line)
+		}
+	}
+	if slices.Equal(old, added) {
+		return recurrence
+	}
+	return append(replaced, added...)
+}
+
+// TruncateRecurrence ends a series before the occurrence starting at
+// before, which is how "this and following" splits a series in two.
+func TruncateRecurrence(recurrence []string, before time.Time, allDay bool) ([]string, error) {
+	var truncated []string
+	for _, line := range recurrence {
+		if !strings.HasPrefix(line, "RRULE:") {
+			truncated = append(truncated, line)
+			continue
+		}
+		rule, err := ParseRRule(line)
+		if err != nil {
+			return nil, err
+		}
+		rule.Count = 0
+		rule.Until = before.Add(-time.Second)
+		if allDay {
+			rule.Until = before.AddDate(0, 0, -1)
+		}
+		truncated = append(truncated, rule.String(allDay))
+	}
+	return truncated, nil
+}
+
+// ExpandRecurrence lists the occurrences of master that overlap the window.
+// Instance ids follow Google's "<master id>_<start>" form.
+func ExpandRecurrence(master Event, timeMin time.Time, timeMax time.Time) []Event {
+	var rule RRule
+	found := false
+	for _, line := range master.Recurrence {
+		if strings.HasPrefix(line, "RRULE:") {
+			parsed, err := ParseRRule(line)
+			if err != nil {
+				return nil
+			}
+			rule = parsed
+			found = true
+		}
+	}
+	if !found {
+		return []Event{master}
+	}
+
+	loc := CalendarLocation()
+	start := master.Start.DateTime.In(loc)
+	duration := master.End.DateTime.Sub(master.Start.DateTime)
+	var instances []Event
+	emitted := 0
+	emit := func(occurrence time.Time) bool {
+		if occurrence.Before(start) {
+			return true
+		}
+		if rule.Count > 0 && emitted >= rule.Count {
+			return false
+		}
+		if !rule.Until.IsZero() && occurrence.After(rule.Until) {
+			return false
+		}
+		if !occurrence.Before(timeMax) {
+			return false
+		}
+		emitted++
+		if occurrence.Add(duration).After(timeMin) {
+			instance := master
+			instance.Recurrence = nil
+			instance.RecurringEventId = master.Id
+			instance.Id = master.Id + "_" + occurrence.UTC().Format("20060102T150405Z")
+			if master.AllDay {
+				instance.Id = master.Id + "_" + occurrence.Format("20060102")
+			}
+			instance.Start.DateTime = occurrence
+			instance.End.DateTime = occurrence.Add(duration)
+			instances = append(instances, normalizeEvent(instance))
+		}
+		return emitted < maxOccurrences
+	}
+	at := func(day time.Time) time.Time {
+		return time.Date(day.Year(), day.Month(), day.Day(), start.Hour(), start.Minute(), start.Second(), 0, loc)
+	}
+
+	for period := 0; period < maxOccurrences; period++ {
+		switch rule.Freq {
+		case "DAILY":
+			if !emit(at(start.AddDate(0, 0, period*rule.Interval))) {
+				return instances
+			}
+		case "WEEKLY":
+			if len(rule.ByDay) == 0 {
+				if !emit(at(start.AddDate(0, 0, 7*period*rule.Interval))) {
+					return instances
+				}
+				continue
+			}
+			// Weeks start on Monday, the RFC 5545 default
+			monday := start.AddDate(0, 0, -((int(start.Weekday())+6)%7)+7*period*rule.Interval)
+			for offset := 0; offset < 7; offset++ {
+				day := monday.AddDate(0, 0, offset)
+				for _, code := range rule.ByDay {
+					if codeWeekdays[code] == day.Weekday() && !emit(at(day)) {
+						return instances
+					}
+				}
+			}
+		case "MONTHLY":
+			if len(rule.ByDay) > 0 || len(rule.ByMonthDay) > 0 {
+				first := time.Date(start.Year(), start.Month()+time.Month(period*rule.Interval), 1, 0, 0, 0, 0, loc)
+				for _, day := range rule.monthDays(first) {
+					if !emit(at(day)) {
+						return instances
+					}
+				}
+				continue
+			}
+			day := start.AddDate(0, period*rule.Interval, 0)
+			if day.Day() == start.Day() && !emit(at(day)) {
+				return instances
+			}
+		case "YEARLY":
+			day := start.AddDate(period*rule.Interval, 0, 0)
+			if day.Day() == start.Day() && !emit(at(day)) {
+				return instances
+			}
+		}
+	}
+	return instances
+}
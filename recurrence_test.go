@@ -0,0 +1,206 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseRRule(t *testing.T) {
+	tests := []struct {
+		line string
+		want RRule
+		ok   bool
+	}{
+		{"RRULE:FREQ=DAILY", RRule{Freq: "DAILY", Interval: 1}, true},
+		{"RRULE:FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE", RRule{Freq: "WEEKLY", Interval: 2, ByDay: []string{"MO", "WE"}}, true},
+		{"RRULE:FREQ=MONTHLY;BYDAY=3TU", RRule{Freq: "MONTHLY", Interval: 1, ByDay: []string{"3TU"}}, true},
+		{"RRULE:FREQ=MONTHLY;BYDAY=-1FR", RRule{Freq: "MONTHLY", Interval: 1, ByDay: []string{"-1FR"}}, true},
+		{"RRULE:FREQ=MONTHLY;BYMONTHDAY=15", RRule{Freq: "MONTHLY", Interval: 1, ByMonthDay: []int{15}}, true},
+		{"RRULE:FREQ=MONTHLY;BYMONTHDAY=1,-1", RRule{Freq: "MONTHLY", Interval: 1, ByMonthDay: []int{1, -1}}, true},
+		{"RRULE:FREQ=DAILY;COUNT=5", RRule{Freq: "DAILY", Interval: 1, Count: 5}, true},
+		{"RRULE:FREQ=DAILY;UNTIL=20260301T000000Z", RRule{Freq: "DAILY", Interval: 1, Until: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)}, true},
+		{"RRULE:FREQ=WEEKLY;BYDAY=2MO", RRule{}, false},
+		{"RRULE:FREQ=YEARLY;BYMONTHDAY=15", RRule{}, false},
+		{"RRULE:FREQ=MONTHLY;BYDAY=9TU", RRule{}, false},
+		{"RRULE:FREQ=MONTHLY;BYMONTHDAY=0", RRule{}, false},
+		{"RRULE:FREQ=HOURLY", RRule{}, false},
+		{"RRULE:FREQ=YEARLY;BYMONTH=3", RRule{}, false},
+		{"RRULE:FREQ", RRule{}, false},
+	}
+	for _, tt := range tests {
+		got, err := ParseRRule(tt.line)
+		if (err == nil) != tt.ok {
+			t.Errorf("ParseRRule(%q) error %v, want ok %v", tt.line, err, tt.ok)
+			continue
+		}
+		if tt.ok && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseRRule(%q) = %+v, want %+v", tt.line, got, tt.want)
+		}
+		if tt.ok && got.String(false) != tt.line {
+			t.Errorf("ParseRRule(%q).String() = %q", tt.line, got.String(false))
+		}
+	}
+}
+
+func TestParseRepeat(t *testing.T) {
+	tests := []struct {
+		spec   string
+		allDay bool
+		want   []string
+		ok     bool
+	}{
+		{"", false, nil, true},
+		{"never", false, nil, true},
+		{"daily", false, []string{"RRULE:FREQ=DAILY"}, true},
+		{"weekdays", false, []string{"RRULE:FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR"}, true},
+		{"every 2 weeks on mon,wed", false, []string{"RRULE:FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE"}, true},
+		{"fri", false, []string{"RRULE:FREQ=WEEKLY;BYDAY=FR"}, true},
+		{"daily count 5", false, []string{"RRULE:FREQ=DAILY;COUNT=5"}, true},
+		{"weekly 3 times", false, []string{"RRULE:FREQ=WEEKLY;COUNT=3"}, true},
+		{"monthly until 2026-12-01", true, []string{"RRULE:FREQ=MONTHLY;UNTIL=20261201"}, true},
+		{"monthly until 2026-12-01", false, []string{"RRULE:FREQ=MONTHLY;UNTIL=20261201T235959Z"}, true},
+		{"RRULE:FREQ=MONTHLY;BYDAY=3TU", false, []string{"RRULE:FREQ=MONTHLY;BYDAY=3TU"}, true},
+		{"daily count 5 until 2026-12-01", false, nil, false},
+		{"until 2026-12-01", false, nil, false},
+		{"sometimes", false, nil, false},
+		{"RRULE:FREQ=HOURLY", false, nil, false},
+	}
+	for _, tt := range tests {
+		got, err := ParseRepeat(tt.spec, tt.allDay)
+		if (err == nil) != tt.ok {
+			t.Errorf("ParseRepeat(%q) error %v, want ok %v", tt.spec, err, tt.ok)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseRepeat(%q) = %q, want %q", tt.spec, got, tt.want)
+		}
+	}
+}
+
+func TestDescribeRecurrence(t *testing.T) {
+	tests := []struct {
+		recurrence []string
+		want       string
+	}{
+		{nil, ""},
+		{[]string{"RRULE:FREQ=DAILY"}, "daily"},
+		{[]string{"RRULE:FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR"}, "weekdays"},
+		{[]string{"RRULE:FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE"}, "every 2 weeks on mon,wed"},
+		{[]string{"EXDATE:20260105T090000Z", "RRULE:FREQ=DAILY;COUNT=5"}, "daily count 5"},
+		{[]string{"RRULE:FREQ=MONTHLY;BYDAY=3TU"}, "RRULE:FREQ=MONTHLY;BYDAY=3TU"},
+		{[]string{"RRULE:FREQ=MONTHLY;BYMONTHDAY=15"}, "RRULE:FREQ=MONTHLY;BYMONTHDAY=15"},
+	}
+	for _, tt := range tests {
+		got := DescribeRecurrence(tt.recurrence)
+		if got != tt.want {
+			t.Errorf("DescribeRecurrence(%q) = %q, want %q", tt.recurrence, got, tt.want)
+		}
+		// What the form shows must be accepted back unchanged
+		if tt.want == "" {
+			continue
+		}
+		rule, err := ParseRepeat(got, false)
+		if err != nil {
+			t.Errorf("ParseRepeat(%q): %v", got, err)
+		} else if last := tt.recurrence[len(tt.recurrence)-1]; rule[0] != last {
+			t.Errorf("ParseRepeat(%q) = %q, want %q", got, rule[0], last)
+		}
+	}
+}
+
+func TestReplaceRule(t *testing.T) {
+	tests := []struct {
+		recurrence []string
+		rules      []string
+		want       []string
+	}{
+		{[]string{"RRULE:FREQ=DAILY"}, []string{"RRULE:FREQ=WEEKLY"}, []string{"RRULE:FREQ=WEEKLY"}},
+		{[]string{"EXDATE:20260105T090000Z", "RRULE:FREQ=DAILY", "RDATE:20260110T090000Z"}, []string{"RRULE:FREQ=WEEKLY"},
+			[]string{"EXDATE:20260105T090000Z", "RDATE:20260110T090000Z", "RRULE:FREQ=WEEKLY"}},
+		{[]string{"RRULE:FREQ=DAILY", "EXDATE:20260105T090000Z"}, []string{"RRULE:FREQ=DAILY"},
+			[]string{"RRULE:FREQ=DAILY", "EXDATE:20260105T090000Z"}},
+	}
+	for _, tt := range tests {
+		got := ReplaceRule(tt.recurrence, tt.rules)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ReplaceRule(%q, %q) = %q, want %q", tt.recurrence, tt.rules, got, tt.want)
+		}
+	}
+}
+
+func TestTruncateRecurrence(t *testing.T) {
+	before := time.Date(2026, 3, 10, 9, 0, 0, 0, time.UTC)
+	tests := []struct {
+		recurrence []string
+		allDay     bool
+		want       []string
+	}{
+		{[]string{"RRULE:FREQ=DAILY;COUNT=10"}, false, []string{"RRULE:FREQ=DAILY;UNTIL=20260310T085959Z"}},
+		{[]string{"EXDATE:20260105", "RRULE:FREQ=WEEKLY;BYDAY=TU"}, true, []string{"EXDATE:20260105", "RRULE:FREQ=WEEKLY;BYDAY=TU;UNTIL=20260309"}},
+		{[]string{"RRULE:FREQ=MONTHLY;BYDAY=2TU"}, false, []string{"RRULE:FREQ=MONTHLY;BYDAY=2TU;UNTIL=20260310T085959Z"}},
+	}
+	for _, tt := range tests {
+		got, err := TruncateRecurrence(tt.recurrence, before, tt.allDay)
+		if err != nil {
+			t.Errorf("TruncateRecurrence(%q): %v", tt.recurrence, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("TruncateRecurrence(%q) = %q, want %q", tt.recurrence, got, tt.want)
+		}
+	}
+}
+
+func TestExpandRecurrence(t *testing.T) {
+	start := time.Date(2026, 1, 6, 9, 0, 0, 0, time.UTC) // a Tuesday
+	tests := []struct {
+		rule string
+		want []string
+	}{
+		{"RRULE:FREQ=DAILY;COUNT=3", []string{"2026-01-06", "2026-01-07", "2026-01-08"}},
+		{"RRULE:FREQ=WEEKLY;BYDAY=TU,TH;COUNT=4", []string{"2026-01-06", "2026-01-08", "2026-01-13", "2026-01-15"}},
+		{"RRULE:FREQ=MONTHLY;COUNT=3", []string{"2026-01-06", "2026-02-06", "2026-03-06"}},
+		{"RRULE:FREQ=MONTHLY;BYDAY=3TU;COUNT=3", []string{"2026-01-20", "2026-02-17", "2026-03-17"}},
+		{"RRULE:FREQ=MONTHLY;BYDAY=-1FR;COUNT=3", []string{"2026-01-30", "2026-02-27", "2026-03-27"}},
+		{"RRULE:FREQ=MONTHLY;BYMONTHDAY=15,-1;COUNT=4", []string{"2026-01-15", "2026-01-31", "2026-02-15", "2026-02-28"}},
+		{"RRULE:FREQ=MONTHLY;INTERVAL=2;BYMONTHDAY=31;COUNT=2", []string{"2026-01-31", "2026-03-31"}},
+	}
+	for _, tt := range tests {
+		master := Event{
+			Id:         "series",
+			Start:      DateTime{DateTime: start},
+			End:        DateTime{DateTime: start.Add(time.Hour)},
+			Recurrence: []string{tt.rule},
+		}
+		var got []string
+		for _, instance := range ExpandRecurrence(master, start, start.AddDate(1, 0, 0)) {
+			got = append(got, instance.Start.DateTime.Format(time.DateOnly))
+			if instance.Start.DateTime.Hour() != 9 {
+				t.Errorf("%s: occurrence at %v, want 9:00", tt.rule, instance.Start.DateTime)
+			}
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s expands to %q, want %q", tt.rule, got, tt.want)
+		}
+	}
+}
+
+func TestInstanceStart(t *testing.T) {
+	start := time.Date(2026, 1, 6, 11, 0, 0, 0, time.UTC)
+	tests := []struct {
+		id   string
+		want time.Time
+	}{
+		{"abc_20260106T090000Z", time.Date(2026, 1, 6, 9, 0, 0, 0, time.UTC)},
+		{"abc_20260106", time.Date(2026, 1, 6, 0, 0, 0, 0, time.UTC)},
+		{"abc", start},
+		{"abc_moved", start},
+	}
+	for _, tt := range tests {
+		got := InstanceStart(Event{Id: tt.id, Start: DateTime{DateTime: start}})
+		if !got.Equal(tt.want) {
+			t.Errorf("InstanceStart(%q) = %v, want %v", tt.id, got, tt.want)
+		}
+	}
+}
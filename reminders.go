@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// The API allows at most five overrides, each up to four weeks ahead.
+const (
+	maxReminders       = 5
+	maxReminderMinutes = 4 * 7 * 24 * 60
+)
+
+type Reminder struct {
+	Method  string `json:"method"`
+	Minutes int    `json:"minutes"`
+}
+
+// FormatMinutes shortens a reminder offset to the largest whole unit, like
+// 90 -> "90m", 120 -> "2h" and 2880 -> "2d".
+func FormatMinutes(minutes int) string {
+	switch {
+	case minutes == 0:
+		return "0m"
+	case minutes%(7*24*60) == 0:
+		return fmt.Sprintf("%dw", minutes/(7*24*60))
+	case minutes%(24*60) == 0:
+		return fmt.Sprintf("%dd", minutes/(24*60))
+	case minutes%60 == 0:
+		return fmt.Sprintf("%dh", minutes/60)
+	}
+	return fmt.Sprintf("%dm", minutes)
+}
+
+func parseMinutes(value string) (int, error) {
+	unit := 1
+	switch {
+	case strings.HasSuffix(value, "w"):
+		unit = 7 * 24 * 60
+	case strings.HasSuffix(value, "d"):
+		unit = 24 * 60
+	case strings.HasSuffix(value, "h"):
+		unit = 60
+	}
+	n, err := strconv.Atoi(strings.TrimRight(value, "wdhm"))
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a time like 10m, 1h or 2d", value)
+	}
+	return n * unit, nil
+}
+
+// DescribeReminders is the text the form shows for reminders, the inverse
+// of ParseReminders.
+func DescribeReminders(reminders []Reminder) string {
+	if len(reminders) == 0 {
+		return "none"
+	}
+	var parts []string
+	for _, reminder := range reminders {
+		parts = append(parts, reminder.Method+" "+FormatMinutes(reminder.Minutes))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// ParseReminders reads "default", "none" or a comma separated list like
+// "popup 10m, email 1d". It returns custom false when the calendar's
+// defaults should be used.
+func ParseReminders(value string) (reminders []Reminder, custom bool, err error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	switch value {
+	case "", "default":
+		return nil, false, nil
+	case "none":
+		return nil, true, nil
+	}
+	for _, part := range strings.Split(value, ",") {
+		fields := strings.Fields(part)
+		if len(fields) == 0 {
+			continue
+		}
+		reminder := Reminder{Method: "popup"}
+		if len(fields) > 2 {
+			return nil, false, fmt.Errorf("%q is not a reminder like popup 10m", strings.TrimSpace(part))
+		}
+		if len(fields) == 2 {
+			reminder.Method = fields[0]
+			fields = fields[1:]
+		}
+		if reminder.Method != "popup" && reminder.Method != "email" {
+			return nil, false, fmt.Errorf("reminders are popup or email, not %q", reminder.Method)
+		}
+		reminder.Minutes, err = parseMinutes(fields[0])
+		if err != nil {
+			return nil, false, err
+		}
+		if reminder.Minutes < 0 || reminder.Minutes > maxReminderMinutes {
+			return nil, false, fmt.Errorf("reminders can be at most 4 weeks before")
+		}
+		reminders = append(reminders, reminder)
+	}
+	if len(reminders) > maxReminders {
+		return nil, false, fmt.Errorf("at most %d reminders", maxReminders)
+	}
+	return reminders, true, nil
+}
+
+// EventReminders describes the reminders event will fire, falling back to
+// the defaults of the calendar it is in.
+func EventReminders(event Event, cal Calendar) string {
+	if event.CustomReminders {
+		return DescribeReminders(event.Reminders)
+	}
+	return "calendar default (" + DescribeReminders(cal.DefaultReminders) + ")"
+}
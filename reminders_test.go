@@ -0,0 +1,47 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseReminders(t *testing.T) {
+	tests := []struct {
+		value     string
+		reminders []Reminder
+		custom    bool
+		ok        bool
+	}{
+		{"", nil, false, true},
+		{"default", nil, false, true},
+		{"none", nil, true, true},
+		{"10m", []Reminder{{"popup", 10}}, true, true},
+		{"popup 10m, email 1d", []Reminder{{"popup", 10}, {"email", 24 * 60}}, true, true},
+		{"Email 2H", []Reminder{{"email", 120}}, true, true},
+		{"popup 1w", []Reminder{{"popup", 7 * 24 * 60}}, true, true},
+		{"popup 4w", []Reminder{{"popup", maxReminderMinutes}}, true, true},
+		{"popup 5w", nil, false, false},
+		{"sms 10m", nil, false, false},
+		{"popup soon", nil, false, false},
+		{"popup 10m now", nil, false, false},
+		{"1m, 2m, 3m, 4m, 5m, 6m", nil, false, false},
+	}
+	for _, tt := range tests {
+		reminders, custom, err := ParseReminders(tt.value)
+		if (err == nil) != tt.ok {
+			t.Errorf("ParseReminders(%q) error %v, want ok %v", tt.value, err, tt.ok)
+			continue
+		}
+		if !reflect.DeepEqual(reminders, tt.reminders) || custom != tt.custom {
+			t.Errorf("ParseReminders(%q) = %v, %v, want %v, %v", tt.value, reminders, custom, tt.reminders, tt.custom)
+		}
+		if !tt.ok || !custom {
+			continue
+		}
+		// What the form shows must read back the same
+		again, _, err := ParseReminders(DescribeReminders(reminders))
+		if err != nil || !reflect.DeepEqual(again, reminders) {
+			t.Errorf("ParseReminders(DescribeReminders(%v)) = %v, %v", reminders, again, err)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// searchDays is how far before and after today a calendar search looks
+	searchDays = 365
+	// searchLimit is the most results a search returns for each calendar
+	// before today, and again from today on
+	searchLimit = 50
+	// searchPageSize is how many matches one request asks for
+	searchPageSize = 250
+	// searchMaxPages is how many pages a search reads at most in each direction
+	searchMaxPages = 10
+)
+
+// MatchesSearch reports whether every word of text appears in the event's
+// summary, location, description, organizer or guests, ignoring case.
+func MatchesSearch(event Event, text string) bool {
+	fields := []string{event.Summary, event.Location, event.Description, event.Organizer.Email, event.Organizer.DisplayName}
+	for _, attendee := range event.Attendees {
+		fields = append(fields, attendee.Email, attendee.DisplayName)
+	}
+	haystack := strings.ToLower(strings.Join(fields, "\n"))
+	for _, word := range strings.Fields(strings.ToLower(text)) {
+		if !strings.Contains(haystack, word) {
+			return false
+		}
+	}
+	return true
+}
+
+// FilterEvents keeps the events matching text, all of them when text is
+// blank.
+func FilterEvents(events []Event, text string) []Event {
+	if strings.TrimSpace(text) == "" {
+		return events
+	}
+	var matches []Event
+	for _, event := range events {
+		if MatchesSearch(event, text) {
+			matches = append(matches, event)
+		}
+	}
+	return matches
+}
+
+// SearchBounds is the time a calendar search covers.
+func SearchBounds() (time.Time, time.Time) {
+	today := StartOfDay(Now())
+	return today.AddDate(0, 0, -searchDays), today.AddDate(0, 0, searchDays)
+}
+
+// GetSearchResults asks Google for the events of a calendar matching text,
+// using the API's own free text search. It keeps the matches nearest
+// today: up to searchLimit from today on and as many before it.
+func GetSearchResults(config apiConfig, calendarID string, text string, timeMin time.Time, timeMax time.Time) ([]Event, error) {
+	split := StartOfDay(Now())
+	if split.Before(timeMin) {
+		split = timeMin
+	}
+	if split.After(timeMax) {
+		split = timeMax
+	}
+	events, err := searchPages(config, calendarID, text, timeMin, split, false)
+	if err != nil {
+		return nil, err
+	}
+	upcoming, err := searchPages(config, calendarID, text, split, timeMax, true)
+	if err != nil {
+		return nil, err
+	}
+	// Events going on at the split are found by both
+	seen := make(map[string]bool)
+	for _, event := range events {
+		seen[event.Id] = true
+	}
+	for _, event := range upcoming {
+		if !seen[event.Id] {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+// searchPages reads the matches between timeMin and timeMax in start order.
+// With first it stops at the first searchLimit of them, otherwise it reads
+// on, up to searchMaxPages pages, and keeps the last searchLimit.
+func searchPages(config apiConfig, calendarID string, text string, timeMin time.Time, timeMax time.Time, first bool) ([]Event, error) {
+	if !timeMin.Before(timeMax) {
+		return nil, nil
+	}
+	q := url.Values{}
+	q.Add("q", text)
+	q.Add("timeMin", timeMin.UTC().Format(time.RFC3339))
+	q.Add("timeMax", timeMax.UTC().Format(time.RFC3339))
+	q.Add("orderBy", "startTime")
+	q.Add("singleEvents", "true")
+	q.Add("maxResults", strconv.Itoa(searchPageSize))
+
+	var events []Event
+	for page := 0; page < searchMaxPages; page++ {
+		calendarEvent, err := getEventsPage(config, calendarID, q)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range calendarEvent.Items {
+			event, ok, err := ParseCalendarItem(item)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				event.CalendarID = calendarID
+				events = append(events, event)
+			}
+		}
+		if first && len(events) >= searchLimit || calendarEvent.NextPageToken == "" {
+			break
+		}
+		q.Set("pageToken", calendarEvent.NextPageToken)
+	}
+	if len(events) > searchLimit {
+		if first {
+			return events[:searchLimit], nil
+		}
+		return events[len(events)-searchLimit:], nil
+	}
+	return events, nil
+}
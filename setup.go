@@ -5,38 +5,150 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
 func Setup() {
+	authFlag := flag.Bool("a", false, "Open Google Oauth on the Browser")
+	demoFlag := flag.Bool("d", false, "Run against an in-memory demo calendar")
+	themeFlag := flag.String("theme", "", "Theme to use: auto, light, dark, high-contrast or a theme file")
+	flag.Parse()
+	LoadConfig(*demoFlag)
+	LoadThemeConfig(*themeFlag)
+	if *demoFlag {
+		calendarBackend = NewMemoryBackend(DemoCalendars(), DemoEvents())
+	} else if *authFlag {
+		fmt.Println("> Opening Google Oauth using default browser\n> http://localhost:8080/auth/google")
+		OpenUrl("http://localhost:8080/auth/google")
+		OauthSpinUp()
+	} else {
+		calendarBackend = NewCalendarBackend()
+	}
+}
+
+// LoadConfig reads {USER_CONFIG}/go-home/.env, creating it and exiting on
+// first run. The demo calendar needs no Google credentials, so with demo a
+// missing file leaves every setting at its default.
+func LoadConfig(demo bool) {
 	configDir, err := os.UserConfigDir()
 	if err != nil {
 		log.Fatalf("Failed to get user config %e", err)
 	}
 	url := configDir + "/go-home/.env"
 	err = godotenv.Load(url)
-	if err != nil {
+	if err != nil && !demo {
 		createConfig()
 		fmt.Println("Creating Config in user config {USER_CONFIG}/go-home/.env")
 		fmt.Println("Use README.md to config your credentials")
 		os.Exit(1)
 	}
-	authFlag := flag.Bool("a", false, "Open Google Oauth on the Browser")
-	flag.Parse()
-	if *authFlag {
-		fmt.Println("> Opening Google Oauth using default browser\n> http://localhost:8080/auth/google")
-		OpenUrl("http://localhost:8080/auth/google")
-		OauthSpinUp()
-	} else {
-		apiConf.accessToken = "Bearer " + os.Getenv("ACCESS_TOKEN")
-		apiConf.calendarID = os.Getenv("CALENDAR_ID")
-		apiConf.refreshToken = os.Getenv("REFRESH_TOKEN")
-		apiConf.clientID = os.Getenv("CLIENT_ID")
-		apiConf.clientSecret = os.Getenv("CLIENT_SECRET")
-		err := RefreshOauth(apiConf)
+	if timeZone := os.Getenv("TIME_ZONE"); timeZone != "" {
+		err = SetCalendarLocation(timeZone)
 		if err != nil {
+			log.Fatalf("Invalid TIME_ZONE %q %e", timeZone, err)
+		}
+		configuredTimeZone = true
+	}
+	hideDeclined = IsYes(os.Getenv("HIDE_DECLINED"))
+	if days := os.Getenv("WINDOW_DAYS"); days != "" {
+		windowDays, err = ParseWindowDays(days)
+		if err != nil {
+			log.Fatalf("Invalid WINDOW_DAYS %v", err)
+		}
+	}
+	weekStart, err = ParseWeekStart(os.Getenv("WEEK_START"))
+	if err != nil {
+		log.Fatalf("Invalid WEEK_START %v", err)
+	}
+	if hours := os.Getenv("WORKING_HOURS"); hours != "" {
+		workStart, workEnd, err = ParseWorkingHours(hours)
+		if err != nil {
+			log.Fatalf("Invalid WORKING_HOURS %v", err)
+		}
+	}
+	keys, err = LoadKeys(os.Getenv("KEYS"), os.Getenv)
+	if err != nil {
+		log.Fatalf("Invalid key bindings %v", err)
+	}
+}
+
+// LoadThemeConfig loads the theme named by --theme, or THEME, with the
+// COLOR_ settings from before themes on top.
+func LoadThemeConfig(name string) {
+	if name == "" {
+		name = os.Getenv("THEME")
+	}
+	var err error
+	theme, err = LoadTheme(name)
+	if err != nil {
+		log.Fatalf("Invalid theme %v", err)
+	}
+	overrides := map[string]string{}
+	for _, color := range []string{"PRIMARY", "WARNING", "ERROR"} {
+		if value := os.Getenv("COLOR_" + color); value != "" {
+			overrides[color] = value
+		}
+	}
+	err = theme.Set(overrides)
+	if err != nil {
+		log.Fatalf("Invalid COLOR_ setting %v", err)
+	}
+}
+
+// NewCalendarBackend connects to Google Calendar with the loaded config,
+// behind the offline journal and the on-disk cache.
+func NewCalendarBackend() CalendarBackend {
+	journal, err := LoadJournal()
+	if err != nil {
+		log.Fatalf("Failed to load offline journal %e", err)
+	}
+	backend := NewOfflineBackend(newGoogleBackend("cache.json"), journal)
+	err = backend.Refresh()
+	if err != nil {
+		log.Fatalf("Failed to refresh Oauth %e", err)
+	}
+	return backend
+}
+
+// NewReadOnlyBackend talks to Google Calendar with the cache but without the
+// offline journal, for processes that run next to the TUI and must not
+// replay or rewrite its queued changes. It keeps its own cache file, and
+// waits for the network when it starts before it is up.
+func NewReadOnlyBackend() CalendarBackend {
+	backend := newGoogleBackend("notify-cache.json")
+	wait := time.Second
+	for {
+		err := backend.Refresh()
+		if err == nil {
+			return backend
+		}
+		if !isNetworkError(err) {
 			log.Fatalf("Failed to refresh Oauth %e", err)
 		}
+		log.Printf("Failed to refresh Oauth %v, trying again in %v\n", err, wait)
+		time.Sleep(wait)
+		wait = min(wait*2, maxRefreshWait)
+	}
+}
+
+// maxRefreshWait is the longest pause between tries to reach Google when
+// the notifier starts offline.
+const maxRefreshWait = 5 * time.Minute
+
+// newGoogleBackend connects with the loaded config and the event cache in
+// the file cacheName.
+func newGoogleBackend(cacheName string) *GoogleBackend {
+	apiConf.accessToken = "Bearer " + os.Getenv("ACCESS_TOKEN")
+	apiConf.calendarID = os.Getenv("CALENDAR_ID")
+	apiConf.visibleCalendars = ParseVisibleCalendars(os.Getenv("VISIBLE_CALENDARS"), apiConf.calendarID)
+	apiConf.refreshToken = os.Getenv("REFRESH_TOKEN")
+	apiConf.clientID = os.Getenv("CLIENT_ID")
+	apiConf.clientSecret = os.Getenv("CLIENT_SECRET")
+	cache, err := LoadEventCache(cacheName)
+	if err != nil {
+		log.Printf("Failed to load event cache %v", err)
 	}
+	return NewGoogleBackend(&apiConf, cache)
 }
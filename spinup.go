@@ -20,80 +20,94 @@ type CalendarEvent struct {
 		Method  string `json:"method"`
 		Minutes int    `json:"minutes"`
 	} `json:"defaultReminders"`
-	NextSyncToken string `json:"nextSyncToken"`
-	Items         []struct {
-		Kind        string    `json:"kind"`
-		Etag        string    `json:"etag"`
-		ID          string    `json:"id"`
-		Status      string    `json:"status"`
-		HTMLLink    string    `json:"htmlLink"`
-		Created     time.Time `json:"created"`
-		Updated     time.Time `json:"updated"`
-		Summary     string    `json:"summary"`
-		Description string    `json:"description,omitempty"`
-		Location    string    `json:"location,omitempty"`
-		Creator     struct {
-			Email string `json:"email"`
-			Self  bool   `json:"self"`
-		} `json:"creator"`
-		Organizer struct {
-			Email string `json:"email"`
-			Self  bool   `json:"self"`
-		} `json:"organizer"`
-		Start struct {
-			DateTime string `json:"dateTime"`
-			Date     string `json:"date"`
-			TimeZone string `json:"timeZone"`
-		} `json:"start"`
-		End struct {
-			DateTime string `json:"dateTime"`
-			Date     string `json:"date"`
-			TimeZone string `json:"timeZone"`
-		} `json:"end"`
-		Transparency string `json:"transparency,omitempty"`
-		Visibility   string `json:"visibility,omitempty"`
-		ICalUID      string `json:"iCalUID"`
-		Sequence     int    `json:"sequence"`
-		Attendees    []struct {
-			Email          string `json:"email"`
-			Organizer      bool   `json:"organizer"`
-			Self           bool   `json:"self"`
-			ResponseStatus string `json:"responseStatus"`
-		} `json:"attendees"`
-		GuestsCanInviteOthers bool `json:"guestsCanInviteOthers,omitempty"`
-		Reminders             struct {
-			UseDefault bool `json:"useDefault"`
-		} `json:"reminders"`
-		Source struct {
-			URL   string `json:"url"`
-			Title string `json:"title"`
-		} `json:"source"`
-		EventType      string `json:"eventType"`
-		ConferenceData struct {
-			EntryPoints []struct {
-				EntryPointType string `json:"entryPointType"`
-				URI            string `json:"uri"`
-				Label          string `json:"label"`
-				MeetingCode    string `json:"meetingCode"`
-			} `json:"entryPoints"`
-			ConferenceSolution struct {
-				Key struct {
-					Type string `json:"type"`
-				} `json:"key"`
-				Name    string `json:"name"`
-				IconURI string `json:"iconUri"`
-			} `json:"conferenceSolution"`
-			ConferenceID string `json:"conferenceId"`
-		} `json:"conferenceData"`
-	} `json:"items"`
+	NextPageToken string         `json:"nextPageToken"`
+	NextSyncToken string         `json:"nextSyncToken"`
+	Items         []CalendarItem `json:"items"`
+}
+
+type CalendarItem struct {
+	Kind        string    `json:"kind"`
+	Etag        string    `json:"etag"`
+	ID          string    `json:"id"`
+	Status      string    `json:"status"`
+	HTMLLink    string    `json:"htmlLink"`
+	Created     time.Time `json:"created"`
+	Updated     time.Time `json:"updated"`
+	Summary     string    `json:"summary"`
+	Description string    `json:"description,omitempty"`
+	Location    string    `json:"location,omitempty"`
+	Creator     struct {
+		Email string `json:"email"`
+		Self  bool   `json:"self"`
+	} `json:"creator"`
+	Organizer struct {
+		Email       string `json:"email"`
+		DisplayName string `json:"displayName"`
+		Self        bool   `json:"self"`
+	} `json:"organizer"`
+	Start struct {
+		DateTime string `json:"dateTime"`
+		Date     string `json:"date"`
+		TimeZone string `json:"timeZone"`
+	} `json:"start"`
+	End struct {
+		DateTime string `json:"dateTime"`
+		Date     string `json:"date"`
+		TimeZone string `json:"timeZone"`
+	} `json:"end"`
+	Transparency string `json:"transparency,omitempty"`
+	Visibility   string `json:"visibility,omitempty"`
+	ICalUID      string `json:"iCalUID"`
+	Sequence     int    `json:"sequence"`
+	Attendees    []struct {
+		Email          string `json:"email"`
+		DisplayName    string `json:"displayName"`
+		Organizer      bool   `json:"organizer"`
+		Self           bool   `json:"self"`
+		Optional       bool   `json:"optional"`
+		ResponseStatus string `json:"responseStatus"`
+		Comment        string `json:"comment"`
+	} `json:"attendees"`
+	// The API leaves out permissions that are at their default of true
+	GuestsCanInviteOthers   *bool `json:"guestsCanInviteOthers,omitempty"`
+	GuestsCanSeeOtherGuests *bool `json:"guestsCanSeeOtherGuests,omitempty"`
+	GuestsCanModify         bool  `json:"guestsCanModify,omitempty"`
+	Reminders               struct {
+		UseDefault bool       `json:"useDefault"`
+		Overrides  []Reminder `json:"overrides"`
+	} `json:"reminders"`
+	Source struct {
+		URL   string `json:"url"`
+		Title string `json:"title"`
+	} `json:"source"`
+	EventType        string   `json:"eventType"`
+	Recurrence       []string `json:"recurrence"`
+	RecurringEventID string   `json:"recurringEventId"`
+	ConferenceData   struct {
+		EntryPoints []struct {
+			EntryPointType string `json:"entryPointType"`
+			URI            string `json:"uri"`
+			Label          string `json:"label"`
+			MeetingCode    string `json:"meetingCode"`
+		} `json:"entryPoints"`
+		ConferenceSolution struct {
+			Key struct {
+				Type string `json:"type"`
+			} `json:"key"`
+			Name    string `json:"name"`
+			IconURI string `json:"iconUri"`
+		} `json:"conferenceSolution"`
+		ConferenceID string `json:"conferenceId"`
+	} `json:"conferenceData"`
 }
 
 type apiConfig struct {
-	accessToken  string
-	refreshToken string
-	calendarID   string
-	clientID     string
-	clientSecret string
+	accessToken      string
+	refreshToken     string
+	calendarID       string
+	visibleCalendars []string
+	clientID         string
+	clientSecret     string
 }
 
 func corsMiddleware(next http.Handler) http.Handler {
@@ -29,29 +29,23 @@ type Styles struct {
 	hoverCardEventStyle     lipgloss.Style
 	hoverEmptyEventStyle    lipgloss.Style
 	whiteText               lipgloss.Style
+	todayStyle              lipgloss.Style
+	spinnerStyle            lipgloss.Style
+	allDayEventStyle        lipgloss.Style
 	errorStyle              lipgloss.Style
 	warningStyle            lipgloss.Style
+	invitedBorder           lipgloss.Border
 }
-type color struct {
-	primary   string
-	secondary string
-	warning   string
-	error     string
-}
-
-var colors color
 
 func SetStyles() Styles {
-	colors.primary = os.Getenv("COLOR_PRIMARY")
-	colors.warning = os.Getenv("COLOR_WARNING")
-	colors.error = os.Getenv("COLOR_ERROR")
+	t := theme.withFallbacks()
 	w, _, _ := term.GetSize(os.Stdout.Fd())
 	myStyles := Styles{}
-	myStyles.focusedStyle = lipgloss.NewStyle().Foreground(lipgloss.Color(colors.primary))
-	myStyles.cursorStyle = myStyles.focusedStyle
+	myStyles.focusedStyle = lipgloss.NewStyle().Foreground(lipgloss.Color(t.Focused))
+	myStyles.cursorStyle = lipgloss.NewStyle().Foreground(lipgloss.Color(t.Cursor))
 	myStyles.noStyle = lipgloss.NewStyle()
 
-	myStyles.blurredStyle = lipgloss.NewStyle().Foreground(lipgloss.Color(colors.primary))
+	myStyles.blurredStyle = lipgloss.NewStyle().Foreground(lipgloss.Color(t.Blurred))
 	myStyles.blurredSubmitButton = fmt.Sprintf("[ %s ]", myStyles.blurredStyle.Render("Submit"))
 	myStyles.focusedSubmitButton = myStyles.focusedStyle.Render("[ Submit ]")
 
@@ -61,46 +55,70 @@ func SetStyles() Styles {
 	myStyles.blurredDeleteButton = fmt.Sprintf("[ %s ]", myStyles.blurredStyle.Render("Delete"))
 	myStyles.focusedDeleteButton = myStyles.focusedStyle.Render("[ Delete ]")
 
-	myStyles.grayBlurredStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#808080"))
+	myStyles.grayBlurredStyle = lipgloss.NewStyle().Foreground(lipgloss.Color(t.Muted))
 	myStyles.grayBlurredDeleteButton = myStyles.grayBlurredStyle.Render("[ Delete ]")
 
 	myStyles.dayStyle = lipgloss.NewStyle().
-		Width((w / 8) + 2).
+		Foreground(lipgloss.Color(t.DayHeader)).
+		Width((w / (WindowDays() + 1)) + 2).
 		Align(lipgloss.Center)
+	myStyles.todayStyle = myStyles.dayStyle.
+		Foreground(lipgloss.Color(t.Today)).
+		Bold(true)
 
 	myStyles.addEventStyle = lipgloss.NewStyle().
 		Border(lipgloss.RoundedBorder(), true, true, false, true).
-		Width(w / 8).
+		BorderForeground(lipgloss.Color(t.AddBorder)).
+		Width(w / (WindowDays() + 1)).
 		Height(1).
 		Align(lipgloss.Center)
 	myStyles.cardEventStyle = lipgloss.NewStyle().
 		Border(lipgloss.RoundedBorder(), true, true, false, true).
-		Width(w / 8).
+		BorderForeground(lipgloss.Color(t.CardBorder)).
+		Foreground(lipgloss.Color(t.CardText)).
+		Width(w / (WindowDays() + 1)).
 		Height(5).
 		Align(lipgloss.Center)
 
 	myStyles.emptyEventStyle = lipgloss.NewStyle().
-		Width((w / 8) + 2).
+		Width((w / (WindowDays() + 1)) + 2).
 		Height(5).
 		Align(lipgloss.Center)
 
-	myStyles.hoverAddEventStyle = lipgloss.NewStyle().
-		BorderForeground(lipgloss.Color(colors.primary)).
-		Inherit(myStyles.addEventStyle)
+	myStyles.hoverAddEventStyle = myStyles.addEventStyle.
+		BorderForeground(lipgloss.Color(t.HoverBorder))
 
-	myStyles.hoverCardEventStyle = lipgloss.NewStyle().
-		BorderForeground(lipgloss.Color(colors.primary)).
-		Inherit(myStyles.cardEventStyle)
+	myStyles.hoverCardEventStyle = myStyles.cardEventStyle.
+		BorderForeground(lipgloss.Color(t.HoverBorder))
 
 	myStyles.hoverEmptyEventStyle = lipgloss.NewStyle().
 		Inherit(myStyles.emptyEventStyle)
 
 	myStyles.whiteText = lipgloss.NewStyle().
-		Foreground(lipgloss.Color("#FAFAFA"))
+		Foreground(lipgloss.Color(t.Text))
+
+	myStyles.allDayEventStyle = lipgloss.NewStyle().
+		Background(lipgloss.Color(t.AllDayBackground)).
+		Foreground(lipgloss.Color(t.AllDayText)).
+		Align(lipgloss.Center)
+
+	// Dashed like an unconfirmed block in most calendar apps
+	myStyles.invitedBorder = lipgloss.Border{
+		Top:         "╌",
+		Bottom:      "╌",
+		Left:        "╎",
+		Right:       "╎",
+		TopLeft:     "╭",
+		TopRight:    "╮",
+		BottomLeft:  "╰",
+		BottomRight: "╯",
+	}
 
 	myStyles.errorStyle = lipgloss.NewStyle().
-		Foreground(lipgloss.Color(colors.error))
+		Foreground(lipgloss.Color(t.Error))
 	myStyles.warningStyle = lipgloss.NewStyle().
-		Foreground(lipgloss.Color(colors.warning))
+		Foreground(lipgloss.Color(t.Warning))
+	myStyles.spinnerStyle = lipgloss.NewStyle().
+		Foreground(lipgloss.Color(t.Spinner))
 	return myStyles
 }
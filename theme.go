@@ -0,0 +1,184 @@
+package main
+
+import (
+	"embed"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/joho/godotenv"
+)
+
+//go:embed themes/*.theme
+var bundledThemes embed.FS
+
+// Theme is the color of each element go-home draws, as "#rrggbb", "#rgb" or
+// an ANSI color from 0 to 255. Empty colors fall back to another one of the
+// theme, or to the terminal's own.
+type Theme struct {
+	Primary          string
+	Secondary        string
+	Warning          string
+	Error            string
+	Muted            string
+	Text             string
+	Focused          string
+	Cursor           string
+	Blurred          string
+	DayHeader        string
+	Today            string
+	CardBorder       string
+	CardText         string
+	AddBorder        string
+	HoverBorder      string
+	AllDayBackground string
+	AllDayText       string
+	Spinner          string
+}
+
+// themeColor is a color of a theme as it is named in a theme file.
+type themeColor struct {
+	name  string
+	color *string
+}
+
+func (t *Theme) colors() []themeColor {
+	return []themeColor{
+		{"PRIMARY", &t.Primary},
+		{"SECONDARY", &t.Secondary},
+		{"WARNING", &t.Warning},
+		{"ERROR", &t.Error},
+		{"MUTED", &t.Muted},
+		{"TEXT", &t.Text},
+		{"FOCUSED", &t.Focused},
+		{"CURSOR", &t.Cursor},
+		{"BLURRED", &t.Blurred},
+		{"DAY_HEADER", &t.DayHeader},
+		{"TODAY", &t.Today},
+		{"CARD_BORDER", &t.CardBorder},
+		{"CARD_TEXT", &t.CardText},
+		{"ADD_BORDER", &t.AddBorder},
+		{"HOVER_BORDER", &t.HoverBorder},
+		{"ALL_DAY_BACKGROUND", &t.AllDayBackground},
+		{"ALL_DAY_TEXT", &t.AllDayText},
+		{"SPINNER", &t.Spinner},
+	}
+}
+
+var theme Theme
+
+var colorPattern = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// LoadTheme finds a theme by name: "auto" picks light or dark from the
+// terminal's background, a path reads that file, and other names are looked
+// up in {USER_CONFIG}/go-home/themes before the bundled light, dark and
+// high-contrast.
+func LoadTheme(name string) (Theme, error) {
+	return loadTheme(name, 0)
+}
+
+func loadTheme(name string, depth int) (Theme, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || name == "auto" {
+		name = "light"
+		if lipgloss.HasDarkBackground() {
+			name = "dark"
+		}
+	}
+	if depth > 3 {
+		return Theme{}, fmt.Errorf("theme %q extends too many others", name)
+	}
+	data, err := readTheme(name)
+	if err != nil {
+		return Theme{}, err
+	}
+	values, err := godotenv.Unmarshal(string(data))
+	if err != nil {
+		return Theme{}, fmt.Errorf("theme %q: %v", name, err)
+	}
+	var t Theme
+	if base, ok := values["BASE"]; ok {
+		// Start from another theme and change only some colors
+		t, err = loadTheme(base, depth+1)
+		if err != nil {
+			return Theme{}, err
+		}
+		delete(values, "BASE")
+	}
+	err = t.Set(values)
+	if err != nil {
+		return Theme{}, fmt.Errorf("theme %q: %v", name, err)
+	}
+	return t, nil
+}
+
+func readTheme(name string) ([]byte, error) {
+	if strings.ContainsRune(name, os.PathSeparator) || filepath.Ext(name) == ".theme" {
+		return os.ReadFile(name)
+	}
+	if configDir, err := os.UserConfigDir(); err == nil {
+		data, err := os.ReadFile(filepath.Join(configDir, "go-home", "themes", name+".theme"))
+		if err == nil {
+			return data, nil
+		}
+	}
+	data, err := bundledThemes.ReadFile("themes/" + name + ".theme")
+	if err != nil {
+		return nil, fmt.Errorf("unknown theme %q, use auto, light, dark, high-contrast or a theme file", name)
+	}
+	return data, nil
+}
+
+// Set changes the colors named in values, like PRIMARY="#7e9cd8".
+func (t *Theme) Set(values map[string]string) error {
+	colors := t.colors()
+	for name, value := range values {
+		found := false
+		for _, c := range colors {
+			if c.name == name {
+				if !ValidColor(value) {
+					return fmt.Errorf("%s=%q is not a color like #7e9cd8 or 205", name, value)
+				}
+				*c.color = value
+				found = true
+			}
+		}
+		if !found {
+			return fmt.Errorf("unknown color %s", name)
+		}
+	}
+	return nil
+}
+
+// ValidColor reports whether value is a hex color, an ANSI color number or
+// empty.
+func ValidColor(value string) bool {
+	if value == "" || colorPattern.MatchString(value) {
+		return true
+	}
+	n, err := strconv.Atoi(value)
+	return err == nil && n >= 0 && n <= 255
+}
+
+// withFallbacks fills in the colors a theme leaves empty from the ones it
+// sets.
+func (t Theme) withFallbacks() Theme {
+	fallback := func(color *string, to string) {
+		if *color == "" {
+			*color = to
+		}
+	}
+	fallback(&t.Secondary, t.Primary)
+	fallback(&t.Focused, t.Primary)
+	fallback(&t.Cursor, t.Focused)
+	fallback(&t.Blurred, t.Secondary)
+	fallback(&t.Today, t.Focused)
+	fallback(&t.HoverBorder, t.Primary)
+	fallback(&t.AllDayBackground, t.Primary)
+	fallback(&t.Muted, "#808080")
+	return t
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// calendarLocation is the zone form input is read in and events are shown
+// in. It comes from TIME_ZONE when set, otherwise from the calendar itself.
+var calendarLocation atomic.Pointer[time.Location]
+
+// configuredTimeZone is true when TIME_ZONE overrides the calendar's zone.
+var configuredTimeZone bool
+
+// ZonedBackend is implemented by backends that know their calendar's IANA
+// time zone.
+type ZonedBackend interface {
+	TimeZone() string
+}
+
+func CalendarLocation() *time.Location {
+	if loc := calendarLocation.Load(); loc != nil {
+		return loc
+	}
+	return time.Local
+}
+
+func SetCalendarLocation(name string) error {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return err
+	}
+	calendarLocation.Store(loc)
+	return nil
+}
+
+// Now is the current time in the calendar's zone.
+func Now() time.Time {
+	return time.Now().In(CalendarLocation())
+}
+
+func StartOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// DaysBetween counts calendar days from a to b. It compares dates rather
+// than durations so days that are 23 or 25 hours long around DST still
+// count as one.
+func DaysBetween(a time.Time, b time.Time) int {
+	da := time.Date(a.Year(), a.Month(), a.Day(), 0, 0, 0, 0, time.UTC)
+	db := time.Date(b.Year(), b.Month(), b.Day(), 0, 0, 0, 0, time.UTC)
+	return int(db.Sub(da) / (24 * time.Hour))
+}
+
+// EventDate is the day an event starts on in the calendar's zone.
+func EventDate(event Event) string {
+	return event.Start.DateTime.In(CalendarLocation()).Format(time.DateOnly)
+}
+
+// EventEndDate is the last day an event covers. All-day events end at
+// midnight of the following day, so that day is not counted.
+func EventEndDate(event Event) string {
+	end := event.End.DateTime.In(CalendarLocation())
+	if event.AllDay {
+		end = end.AddDate(0, 0, -1)
+	}
+	return end.Format(time.DateOnly)
+}
+
+// EventWhen describes when an event happens in the calendar's zone, like
+// "Mon 2026-01-05 09:30 - 10:00".
+func EventWhen(event Event) string {
+	start := event.Start.DateTime.In(CalendarLocation())
+	end := event.End.DateTime.In(CalendarLocation())
+	if event.AllDay {
+		if last := EventEndDate(event); last != EventDate(event) {
+			return start.Format("Mon 2006-01-02") + " - " + last + " (all day)"
+		}
+		return start.Format("Mon 2006-01-02") + " (all day)"
+	}
+	if end.Format(time.DateOnly) != start.Format(time.DateOnly) {
+		return start.Format("Mon 2006-01-02 15:04") + " - " + end.Format("Mon 2006-01-02 15:04")
+	}
+	return start.Format("Mon 2006-01-02 15:04") + " - " + end.Format("15:04")
+}
+
+// ZoneName is the IANA name sent to the API next to a dateTime, empty when
+// we only know the machine's local zone.
+func ZoneName() string {
+	name := CalendarLocation().String()
+	if name == "Local" {
+		return ""
+	}
+	return name
+}
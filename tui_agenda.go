@@ -0,0 +1,295 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// AgendaItem is one row of the agenda: an event on one of the days of the
+// week, or the "+" row that adds an event to that day.
+type AgendaItem struct {
+	Day   int
+	Event Event
+}
+
+// AgendaItems lists the events on screen by day. Events spanning several
+// days are listed on each of them, and every day ends with a "+" row.
+func AgendaItems(events []Event) []AgendaItem {
+	var items []AgendaItem
+	first := WindowStart()
+	for day := range WindowDays() {
+		dayStart := first.AddDate(0, 0, day)
+		dayEnd := dayStart.AddDate(0, 0, 1)
+		for _, event := range events {
+			start, end := event.Start.DateTime, event.End.DateTime
+			if start.Before(dayEnd) && (end.After(dayStart) || (start.Equal(end) && !start.Before(dayStart))) {
+				items = append(items, AgendaItem{Day: day, Event: event})
+			}
+		}
+		items = append(items, AgendaItem{Day: day, Event: Event{Summary: "+"}})
+	}
+	return items
+}
+
+// FormatDuration is a length like "45m", "1h 30m" or "2d".
+func FormatDuration(d time.Duration) string {
+	minutes := int(d.Round(time.Minute) / time.Minute)
+	if minutes <= 0 {
+		return "0m"
+	}
+	var parts []string
+	if days := minutes / (24 * 60); days > 0 {
+		parts = append(parts, fmt.Sprintf("%dd", days))
+	}
+	if hours := minutes % (24 * 60) / 60; hours > 0 {
+		parts = append(parts, fmt.Sprintf("%dh", hours))
+	}
+	if minutes%60 > 0 {
+		parts = append(parts, fmt.Sprintf("%dm", minutes%60))
+	}
+	return strings.Join(parts, " ")
+}
+
+// agendaTime is the time range of an item on day, "all day" for all-day
+// events and with … where an event continues from or into another day.
+func agendaTime(event Event, day int) string {
+	if event.AllDay {
+		return "all day"
+	}
+	loc := CalendarLocation()
+	dayStart := WindowStart().AddDate(0, 0, day)
+	dayEnd := dayStart.AddDate(0, 0, 1)
+	start := event.Start.DateTime.In(loc).Format("15:04")
+	end := event.End.DateTime.In(loc).Format("15:04")
+	if event.Start.DateTime.Before(dayStart) {
+		start = "…"
+	}
+	if event.End.DateTime.After(dayEnd) {
+		end = "…"
+	}
+	return start + "-" + end
+}
+
+func agendaDayLabel(day int) string {
+	date := WindowStart().AddDate(0, 0, day)
+	label := date.Format("Mon 2 Jan")
+	switch day - TodayIndex() {
+	case 0:
+		label += " · today"
+	case 1:
+		label += " · tomorrow"
+	}
+	return label
+}
+
+// agendaRows renders the agenda wrapped to the terminal width. itemRows is
+// the first row of every item, so the view can scroll to the cursor.
+func (m Model) agendaRows() (rows []string, itemRows []int) {
+	width := m.help.Width
+	if width <= 0 {
+		width = 80
+	}
+	items := AgendaItems(m.events)
+	label := style.grayBlurredStyle.Render
+	for i, item := range items {
+		if i == 0 || items[i-1].Day != item.Day {
+			if i > 0 {
+				rows = append(rows, "")
+			}
+			rows = append(rows, style.focusedStyle.Bold(true).Render(agendaDayLabel(item.Day)))
+		}
+		itemRows = append(itemRows, len(rows))
+
+		pointer := "  "
+		if i == m.agendaIdx {
+			pointer = style.focusedStyle.Render("› ")
+		}
+		if item.Event.Summary == "+" {
+			text := label("+ add event")
+			if i == m.agendaIdx {
+				text = style.focusedStyle.Render("+ add event")
+			}
+			rows = append(rows, pointer+text)
+			continue
+		}
+
+		event := item.Event
+		marker := "●"
+		if color := m.calendarColor(event); color != "" {
+			marker = lipgloss.NewStyle().Foreground(lipgloss.Color(color)).Render(marker)
+		}
+		summary := rsvpTextStyle(lipgloss.NewStyle(), event)
+		if i == m.agendaIdx {
+			summary = summary.Inherit(style.focusedStyle)
+		}
+		timeColumn := fmt.Sprintf("%-11s", agendaTime(event, item.Day))
+		details := []string{FormatDuration(event.End.DateTime.Sub(event.Start.DateTime))}
+		if event.Location != "" {
+			details = append(details, event.Location)
+		}
+		if name := m.calendarName(event); name != "" {
+			details = append(details, name)
+		}
+
+		// Long summaries wrap under themselves instead of being cut
+		indent := lipgloss.Width(pointer) + lipgloss.Width(timeColumn) + 3
+		body := lipgloss.NewStyle().Width(max(width-indent, 20)).Render(
+			summary.Render(m.markedSummary(CardSummary(event), event)) + "\n" + label(strings.Join(details, " · ")))
+		block := lipgloss.JoinHorizontal(lipgloss.Top, pointer+timeColumn+" "+marker+" ", body)
+		rows = append(rows, strings.Split(block, "\n")...)
+	}
+	return rows, itemRows
+}
+
+// agendaHeight is how many rows of the agenda fit on screen, zero when the
+// terminal size is not known yet.
+func (m Model) agendaHeight() int {
+	if m.height <= 0 {
+		return 0
+	}
+	used := lipgloss.Height(m.help.View(m.keys)) + lipgloss.Height(m.statusLine()) + 2
+	return max(m.height-used, 3)
+}
+
+// scrollAgenda keeps the item under the cursor, and the header of its day
+// when it is the first item, on screen.
+func (m *Model) scrollAgenda() {
+	items := AgendaItems(m.events)
+	m.agendaIdx = max(min(m.agendaIdx, len(items)-1), 0)
+	height := m.agendaHeight()
+	if height == 0 {
+		m.agendaTop = 0
+		return
+	}
+	rows, itemRows := m.agendaRows()
+	first := itemRows[m.agendaIdx]
+	last := len(rows)
+	if m.agendaIdx+1 < len(itemRows) {
+		last = itemRows[m.agendaIdx+1]
+	}
+	if m.agendaIdx == 0 || items[m.agendaIdx-1].Day != items[m.agendaIdx].Day {
+		first--
+	}
+	if first < m.agendaTop {
+		m.agendaTop = first
+	}
+	if last > m.agendaTop+height {
+		m.agendaTop = last - height
+	}
+	m.agendaTop = max(min(m.agendaTop, len(rows)-height), 0)
+}
+
+// agendaItem is the item under the agenda cursor.
+func (m Model) agendaItem() AgendaItem {
+	items := AgendaItems(m.events)
+	if m.agendaIdx < 0 || m.agendaIdx >= len(items) {
+		return AgendaItem{Event: Event{Summary: "+"}}
+	}
+	return items[m.agendaIdx]
+}
+
+// placeAgenda puts the agenda cursor on event, or on the "+" row of day
+// when the event is not listed.
+func (m *Model) placeAgenda(event Event, day int) {
+	m.agendaIdx = 0
+	for i, item := range AgendaItems(m.events) {
+		if event.Id != "" && item.Event.Id == event.Id {
+			m.agendaIdx = i
+			break
+		}
+		if item.Day == day && item.Event.Summary == "+" {
+			m.agendaIdx = i
+			if event.Id == "" {
+				break
+			}
+		}
+	}
+	m.scrollAgenda()
+}
+
+func (m Model) updateAgenda(msg tea.Msg) (tea.Model, tea.Cmd) {
+	m.keys.Flip.SetEnabled(false)
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.help.Width = msg.Width
+		m.scrollAgenda()
+	case tea.KeyMsg:
+		items := AgendaItems(m.events)
+		switch {
+		case msg.String() == "ctrl+c", key.Matches(msg, m.keys.Quit):
+			return m, tea.Quit
+		case key.Matches(msg, m.keys.Help):
+			m.help.ShowAll = !m.help.ShowAll
+		case key.Matches(msg, m.keys.Up):
+			if m.agendaIdx > 0 {
+				m.agendaIdx--
+			}
+		case key.Matches(msg, m.keys.Down):
+			if m.agendaIdx < len(items)-1 {
+				m.agendaIdx++
+			}
+		case key.Matches(msg, m.keys.Left):
+			// To the first item of the day before, or of this day
+			day := m.agendaItem().Day
+			if m.agendaIdx > 0 && items[m.agendaIdx-1].Day != day {
+				day--
+			}
+			for i, item := range items {
+				if item.Day == day {
+					m.agendaIdx = i
+					break
+				}
+			}
+		case key.Matches(msg, m.keys.Right):
+			day := m.agendaItem().Day
+			for i, item := range items {
+				if item.Day > day {
+					m.agendaIdx = i
+					break
+				}
+			}
+		case key.Matches(msg, m.keys.View):
+			m.switchView()
+			return m, nil
+		case key.Matches(msg, m.keys.QuickAdd):
+			return m, m.openQuickAdd()
+		case key.Matches(msg, m.keys.FindTime):
+			return m, m.openFindTime()
+		case key.Matches(msg, m.keys.Details):
+			m.openDetail(m.agendaItem().Event)
+			return m, nil
+		case key.Matches(msg, m.keys.Rsvp):
+			return m, m.openRsvp(m.agendaItem().Event)
+		case key.Matches(msg, m.keys.Calendars):
+			m.mode = calendarPicker
+			m.calendarIdx = 0
+			return m, nil
+		case key.Matches(msg, m.keys.Open):
+			item := m.agendaItem()
+			m.openForm(item.Event, item.Day)
+			if m.formEvent.RecurringEventId != "" {
+				return m, loadSeriesCmd(m.backend, m.formEvent)
+			}
+			return m, nil
+		}
+		m.scrollAgenda()
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.spinner, cmd = m.spinner.Update(msg)
+	return m, cmd
+}
+
+func (m Model) agendaView() string {
+	rows, _ := m.agendaRows()
+	if height := m.agendaHeight(); height > 0 && len(rows) > height {
+		top := max(min(m.agendaTop, len(rows)-height), 0)
+		rows = rows[top : top+height]
+	}
+	return strings.Join(rows, "\n") + "\n"
+}
@@ -0,0 +1,308 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Steps of the batch prompt.
+const (
+	batchPick = iota
+	batchArgument
+	batchCalendarPick
+	batchConfirm
+	batchRunning
+	batchSummary
+)
+
+// batchListRows is how many marked events or results are listed at once.
+const batchListRows = 10
+
+// toggleMark marks the event under the cursor for a batch action, or
+// unmarks it.
+func (m *Model) toggleMark() {
+	event, _ := m.selection()
+	if event.Id == "" || event.Summary == "+" {
+		return
+	}
+	if _, ok := m.marked[event.Id]; ok {
+		delete(m.marked, event.Id)
+	} else {
+		m.marked[event.Id] = event
+	}
+}
+
+// refreshMarks keeps the marked events up to date with what was loaded.
+// Marks on days that are not on screen stay as they are.
+func (m *Model) refreshMarks() {
+	for _, event := range m.loaded {
+		if _, ok := m.marked[event.Id]; ok {
+			m.marked[event.Id] = event
+		}
+	}
+}
+
+// markedEvents are the marked events, earliest first.
+func (m Model) markedEvents() []Event {
+	var events []Event
+	for _, event := range m.marked {
+		events = append(events, event)
+	}
+	sort.Slice(events, func(i, j int) bool {
+		if events[i].Start.DateTime.Equal(events[j].Start.DateTime) {
+			return events[i].Id < events[j].Id
+		}
+		return events[i].Start.DateTime.Before(events[j].Start.DateTime)
+	})
+	return events
+}
+
+// markedSummary puts a ✔ in front of the title of a marked event.
+func (m Model) markedSummary(summary string, event Event) string {
+	if _, ok := m.marked[event.Id]; ok && event.Id != "" {
+		return "✔ " + summary
+	}
+	return summary
+}
+
+// writableCalendars are the calendars events can be moved to, all but the
+// ones shared read only.
+func (m Model) writableCalendars() []Calendar {
+	var calendars []Calendar
+	for _, cal := range m.calendars {
+		if cal.AccessRole != "reader" && cal.AccessRole != "freeBusyReader" {
+			calendars = append(calendars, cal)
+		}
+	}
+	return calendars
+}
+
+// openBatch shows the actions for the marked events.
+func (m *Model) openBatch() {
+	if len(m.marked) == 0 {
+		m.notice = "Mark events with " + keyHint(m.keys.Mark) + " first"
+		return
+	}
+	m.mode = batch
+	m.batchStep = batchPick
+	m.batchIdx = 0
+	m.batchErr = nil
+}
+
+func (m Model) updateBatch(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case batchDoneMsg:
+		m.batchStep = batchSummary
+		m.batchResults = msg.results
+		m.marked = make(map[string]Event)
+		m.pushUndo(UndoEntry{Label: msg.label, Changes: msg.changes})
+		return m, loadEventsCmd(m.backend)
+	case tea.KeyMsg:
+		if msg.String() == "ctrl+c" {
+			return m, tea.Quit
+		}
+		switch m.batchStep {
+		case batchPick:
+			return m.updateBatchPick(msg)
+		case batchArgument:
+			return m.updateBatchArgument(msg)
+		case batchCalendarPick:
+			return m.updateBatchCalendarPick(msg)
+		case batchConfirm:
+			switch msg.String() {
+			case "esc":
+				m.batchStep = batchPick
+			case "enter", "y":
+				m.batchStep = batchRunning
+				events := m.markedEvents()
+				label := m.batchAction.Label(len(events), m.calendars)
+				return m, batchCmd(m.backend, m.batchAction, events, label)
+			}
+		case batchSummary:
+			m.mode = calendar
+		}
+		return m, nil
+	}
+	var cmds [2]tea.Cmd
+	m.batchInput, cmds[0] = m.batchInput.Update(msg)
+	m.spinner, cmds[1] = m.spinner.Update(msg)
+	return m, tea.Batch(cmds[:]...)
+}
+
+func (m Model) updateBatchPick(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch s := msg.String(); {
+	case s == "esc", key.Matches(msg, m.keys.Batch):
+		m.mode = calendar
+	case s == "up", key.Matches(msg, m.keys.Up):
+		if m.batchIdx > 0 {
+			m.batchIdx--
+		}
+	case s == "down", key.Matches(msg, m.keys.Down):
+		if m.batchIdx < len(batchLabels)-1 {
+			m.batchIdx++
+		}
+	case s == "enter":
+		m.batchAction = BatchAction{Kind: m.batchIdx}
+		m.batchErr = nil
+		switch m.batchIdx {
+		case batchDelete:
+			m.batchStep = batchConfirm
+		case batchCalendar:
+			m.batchStep = batchCalendarPick
+			m.calendarIdx = 0
+		default:
+			m.batchStep = batchArgument
+			m.batchInput = textinput.New()
+			m.batchInput.Placeholder = "30m, -1h, 1d"
+			if m.batchIdx == batchCopy {
+				m.batchInput.Placeholder = "tomorrow, fri, 2026-01-05"
+			}
+			m.batchInput.Cursor.Style = style.cursorStyle
+			m.batchInput.PromptStyle = style.focusedStyle
+			m.batchInput.TextStyle = style.focusedStyle
+			return m, m.batchInput.Focus()
+		}
+	}
+	return m, nil
+}
+
+func (m Model) updateBatchArgument(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.batchStep = batchPick
+		return m, nil
+	case "enter":
+		var err error
+		if m.batchAction.Kind == batchShift {
+			m.batchAction.Shift, err = ParseShift(m.batchInput.Value())
+		} else {
+			m.batchAction.Day, err = ParseDay(m.batchInput.Value())
+		}
+		m.batchErr = err
+		if err == nil {
+			m.batchStep = batchConfirm
+		}
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.batchInput, cmd = m.batchInput.Update(msg)
+	m.batchErr = nil
+	return m, cmd
+}
+
+func (m Model) updateBatchCalendarPick(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	calendars := m.writableCalendars()
+	switch s := msg.String(); {
+	case s == "esc":
+		m.batchStep = batchPick
+	case s == "up", key.Matches(msg, m.keys.Up):
+		if m.calendarIdx > 0 {
+			m.calendarIdx--
+		}
+	case s == "down", key.Matches(msg, m.keys.Down):
+		if m.calendarIdx < len(calendars)-1 {
+			m.calendarIdx++
+		}
+	case s == "enter":
+		if m.calendarIdx < len(calendars) {
+			m.batchAction.CalendarID = calendars[m.calendarIdx].Id
+			m.batchStep = batchConfirm
+		}
+	}
+	return m, nil
+}
+
+func (m Model) batchView() string {
+	events := m.markedEvents()
+	switch m.batchStep {
+	case batchPick:
+		s := fmt.Sprintf("\n%d marked\n\n", len(events))
+		s += m.batchEventList(events, func(Event) string { return "" })
+		s += "\n"
+		for i, label := range batchLabels {
+			if i == m.batchIdx {
+				s += style.focusedStyle.Render("> "+label) + "\n"
+			} else {
+				s += "  " + label + "\n"
+			}
+		}
+		return s + "\n" + style.grayBlurredStyle.Render("enter to pick, esc to go back, "+keyHint(m.keys.Mark)+" in the calendar marks more") + "\n"
+	case batchArgument:
+		prompt := "Shift by (30m, -1h, 2d)"
+		if m.batchAction.Kind == batchCopy {
+			prompt = "Copy to day"
+		}
+		s := "\n" + prompt + "\n\n" + m.batchInput.View() + "\n\n"
+		if m.batchErr != nil {
+			s += style.errorStyle.Render(m.batchErr.Error()) + "\n\n"
+		}
+		return s + style.grayBlurredStyle.Render("enter to continue, esc to go back") + "\n"
+	case batchCalendarPick:
+		calendars := m.writableCalendars()
+		s := "\nMove to calendar\n\n"
+		if len(calendars) == 0 {
+			s += style.warningStyle.Render("No calendars you can add events to") + "\n"
+		}
+		for i, cal := range calendars {
+			if i == m.calendarIdx {
+				s += style.focusedStyle.Render("> "+cal.Summary) + "\n"
+			} else {
+				s += "  " + cal.Summary + "\n"
+			}
+		}
+		return s + "\n" + style.grayBlurredStyle.Render("enter to continue, esc to go back") + "\n"
+	case batchConfirm:
+		s := "\n" + style.warningStyle.Render(m.batchAction.Question(len(events), m.calendars)) + "\n\n"
+		s += m.batchEventList(events, func(event Event) string {
+			return m.batchAction.Preview(event, m.calendars)
+		})
+		return s + "\n" + style.grayBlurredStyle.Render("enter to confirm, esc to go back") + "\n"
+	case batchRunning:
+		return "\n" + fmt.Sprintf("Working on %d events %s", len(events), m.spinner.View()) + "\n"
+	}
+
+	failed := 0
+	for _, result := range m.batchResults {
+		if result.Err != nil {
+			failed++
+		}
+	}
+	s := fmt.Sprintf("\n%d of %d done", len(m.batchResults)-failed, len(m.batchResults))
+	if failed < len(m.batchResults) {
+		s += ", " + keyHint(m.keys.Undo) + " undoes them"
+	}
+	s += "\n\n"
+	for i, result := range m.batchResults {
+		if i == batchListRows {
+			s += style.grayBlurredStyle.Render(fmt.Sprintf("  and %d more", len(m.batchResults)-i)) + "\n"
+			break
+		}
+		line := EventWhen(result.Event) + "  " + result.Event.Summary
+		if result.Err != nil {
+			s += style.errorStyle.Render("✗ "+line+": "+result.Err.Error()) + "\n"
+		} else {
+			s += style.focusedStyle.Render("✓ ") + line + "\n"
+		}
+	}
+	return s + "\n" + style.grayBlurredStyle.Render("any key to go back") + "\n"
+}
+
+// batchEventList lists the marked events with a note after each.
+func (m Model) batchEventList(events []Event, note func(Event) string) string {
+	var s string
+	for i, event := range events {
+		if i == batchListRows {
+			return s + style.grayBlurredStyle.Render(fmt.Sprintf("  and %d more", len(events)-i)) + "\n"
+		}
+		s += "  " + EventWhen(event) + "  " + event.Summary
+		if text := note(event); text != "" {
+			s += "  " + style.grayBlurredStyle.Render(text)
+		}
+		s += "\n"
+	}
+	return s
+}
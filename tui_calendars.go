@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+type calendarsLoadedMsg struct {
+	calendars []Calendar
+	err       error
+}
+
+type calendarToggledMsg struct {
+	calendarID string
+	visible    bool
+	err        error
+}
+
+func loadCalendarsCmd(backend CalendarBackend) tea.Cmd {
+	return func() tea.Msg {
+		calendars, err := backend.ListCalendars()
+		return calendarsLoadedMsg{calendars: calendars, err: err}
+	}
+}
+
+func toggleCalendarCmd(backend CalendarBackend, calendarID string, visible bool) tea.Cmd {
+	return func() tea.Msg {
+		err := backend.SetCalendarVisible(calendarID, visible)
+		return calendarToggledMsg{calendarID: calendarID, visible: visible, err: err}
+	}
+}
+
+func (m Model) updateCalendarPicker(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch {
+		case msg.String() == "ctrl+c", key.Matches(msg, m.keys.Quit):
+			return m, tea.Quit
+		case key.Matches(msg, m.keys.Help):
+			m.help.ShowAll = !m.help.ShowAll
+		case msg.String() == "esc", key.Matches(msg, m.keys.Calendars):
+			m.mode = calendar
+		case msg.String() == "up", key.Matches(msg, m.keys.Up):
+			if m.calendarIdx > 0 {
+				m.calendarIdx--
+			}
+		case msg.String() == "down", key.Matches(msg, m.keys.Down):
+			if m.calendarIdx < len(m.calendars)-1 {
+				m.calendarIdx++
+			}
+		case key.Matches(msg, m.keys.Open):
+			if m.calendarIdx < len(m.calendars) {
+				selected := m.calendars[m.calendarIdx]
+				return m, toggleCalendarCmd(m.backend, selected.Id, !selected.Visible)
+			}
+		}
+	}
+	return m, nil
+}
+
+func (m Model) calendarPickerView() string {
+	s := "\nCalendars (space to toggle, esc to close)\n\n"
+	if len(m.calendars) == 0 {
+		s += style.warningStyle.Render("No calendars loaded yet")
+		return s + "\n"
+	}
+	for i, cal := range m.calendars {
+		check := "[ ]"
+		if cal.Visible {
+			check = "[x]"
+		}
+		dot := lipgloss.NewStyle().Foreground(lipgloss.Color(cal.Color)).Render("●")
+		name := cal.Summary
+		if cal.Default {
+			name += " (default)"
+		}
+		line := fmt.Sprintf("%s %s %s", check, dot, name)
+		if i == m.calendarIdx {
+			line = style.focusedStyle.Render("> ") + line
+		} else {
+			line = "  " + line
+		}
+		s += line + "\n"
+	}
+	return s
+}
+
+// calendarName is the name shown for the calendar an event belongs to.
+// Events without a calendar, like the "+" cards, belong to the default one.
+func (m Model) calendarName(event Event) string {
+	if event.CalendarID == "" {
+		if cal, ok := DefaultCalendar(m.calendars); ok {
+			return cal.Summary
+		}
+		return ""
+	}
+	if cal, ok := FindCalendar(m.calendars, event.CalendarID); ok {
+		return cal.Summary
+	}
+	return event.CalendarID
+}
+
+func (m Model) calendarColor(event Event) string {
+	if cal, ok := FindCalendar(m.calendars, event.CalendarID); ok && event.CalendarID != "" {
+		return cal.Color
+	}
+	return ""
+}
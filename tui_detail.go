@@ -0,0 +1,195 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	breakTags = regexp.MustCompile(`(?i)<br\s*/?>|</p>|</div>|</li>`)
+	htmlTags  = regexp.MustCompile(`<[^>]*>`)
+)
+
+// ConferenceLink is the URL to join an event's video call, if it has one.
+func ConferenceLink(event Event) string {
+	for _, entry := range event.Conference.EntryPoints {
+		if entry.Type == "video" {
+			return entry.URI
+		}
+	}
+	return ""
+}
+
+// PlainDescription strips the HTML Google Calendar puts in descriptions
+// written in its web UI.
+func PlainDescription(description string) string {
+	text := breakTags.ReplaceAllString(description, "\n")
+	text = htmlTags.ReplaceAllString(text, "")
+	return strings.TrimSpace(html.UnescapeString(text))
+}
+
+func responseLabel(status string) string {
+	switch status {
+	case "accepted":
+		return "✓ accepted"
+	case "tentative":
+		return "? maybe"
+	case "declined":
+		return "✗ declined"
+	}
+	return "· awaiting"
+}
+
+func personName(email string, displayName string) string {
+	if displayName != "" {
+		return fmt.Sprintf("%s <%s>", displayName, email)
+	}
+	return email
+}
+
+// openDetail shows everything we know about event. The "+" cards have
+// nothing to show.
+func (m *Model) openDetail(event Event) {
+	if event.Id == "" {
+		return
+	}
+	m.detailEvent = event
+	m.mode = detail
+}
+
+func (m Model) updateDetail(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.help.Width = msg.Width
+	case tea.KeyMsg:
+		switch s := msg.String(); {
+		case s == "ctrl+c", key.Matches(msg, m.keys.Quit):
+			return m, tea.Quit
+		case key.Matches(msg, m.keys.Help):
+			m.help.ShowAll = !m.help.ShowAll
+		case s == "esc", key.Matches(msg, m.keys.Details):
+			m.mode = calendar
+		case key.Matches(msg, m.keys.Edit):
+			m.openForm(m.detailEvent, m.cursor.x)
+			if m.formEvent.RecurringEventId != "" {
+				return m, loadSeriesCmd(m.backend, m.formEvent)
+			}
+		case key.Matches(msg, m.keys.Join):
+			m.status = m.openLink(ConferenceLink(m.detailEvent), "video call")
+		case key.Matches(msg, m.keys.Link):
+			m.status = m.openLink(m.detailEvent.HTMLLink, "Google Calendar link")
+		case key.Matches(msg, m.keys.Source):
+			m.status = m.openLink(m.detailEvent.Source.URL, "source link")
+		case key.Matches(msg, m.keys.Rsvp):
+			return m, m.openRsvp(m.detailEvent)
+		}
+	}
+	return m, nil
+}
+
+// openLink opens url in the browser and returns the status to show.
+func (m Model) openLink(url string, name string) string {
+	if url == "" {
+		return fmt.Sprintf("This event has no %s", name)
+	}
+	err := OpenUrl(url)
+	if err != nil {
+		return fmt.Sprintf("Failed to open %s: %v", name, err)
+	}
+	return ""
+}
+
+func (m Model) detailView() string {
+	event := m.detailEvent
+	width := m.help.Width
+	if width <= 0 {
+		width = 80
+	}
+	label := style.grayBlurredStyle.Render
+
+	s := "\n" + style.focusedStyle.Bold(true).Render(event.Summary) + "\n"
+	s += EventWhen(event) + "\n"
+	if repeat := DescribeRecurrence(event.Recurrence); repeat != "" {
+		s += label("Repeats ") + repeat + "\n"
+	} else if event.RecurringEventId != "" {
+		s += label("Part of a recurring event") + "\n"
+	}
+	if event.Location != "" {
+		s += label("Where ") + event.Location + "\n"
+	}
+	if name := m.calendarName(event); name != "" {
+		s += label("Calendar ") + name + "\n"
+	}
+	cal, _ := FindCalendar(m.calendars, event.CalendarID)
+	s += label("Reminders ") + EventReminders(event, cal) + "\n"
+	if event.Organizer.Email != "" {
+		s += label("Organizer ") + personName(event.Organizer.Email, event.Organizer.DisplayName) + "\n"
+	}
+
+	if link := ConferenceLink(event); link != "" {
+		name := event.Conference.Name
+		if name == "" {
+			name = "Video call"
+		}
+		s += "\n" + label(name+" ") + link + "\n"
+		for _, entry := range event.Conference.EntryPoints {
+			if entry.Type == "phone" {
+				s += label("Dial in ") + entry.Label + "\n"
+			}
+		}
+	}
+
+	if len(event.Attendees) > 0 {
+		s += "\n" + label(fmt.Sprintf("Guests (%d)", len(event.Attendees))) + "\n"
+		for _, attendee := range event.Attendees {
+			line := fmt.Sprintf("%-11s %s", responseLabel(attendee.ResponseStatus), personName(attendee.Email, attendee.DisplayName))
+			if attendee.Organizer {
+				line += " (organizer)"
+			}
+			if attendee.Optional {
+				line += " (optional)"
+			}
+			if attendee.Self {
+				line += " (you)"
+			}
+			s += "  " + line + "\n"
+		}
+	}
+
+	if description := PlainDescription(event.Description); description != "" {
+		s += "\n" + lipgloss.NewStyle().Width(min(width, 100)).Render(description) + "\n"
+	}
+	if event.Source.URL != "" {
+		title := event.Source.Title
+		if title == "" {
+			title = event.Source.URL
+		}
+		s += "\n" + label("Source ") + title + "\n"
+	}
+
+	hint := func(binding key.Binding) string {
+		return keyHint(binding) + " " + binding.Help().Desc
+	}
+	var actions []string
+	if ConferenceLink(event) != "" {
+		actions = append(actions, hint(m.keys.Join))
+	}
+	if event.HTMLLink != "" {
+		actions = append(actions, hint(m.keys.Link))
+	}
+	if event.Source.URL != "" {
+		actions = append(actions, hint(m.keys.Source))
+	}
+	if SelfResponse(event) != "" {
+		actions = append(actions, hint(m.keys.Rsvp))
+	}
+	actions = append(actions, hint(m.keys.Edit), "esc back")
+	s += "\n" + label(strings.Join(actions, " • ")) + "\n"
+	return s
+}
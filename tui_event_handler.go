@@ -3,6 +3,7 @@ package main
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -14,228 +15,707 @@ import (
 	"github.com/joho/godotenv"
 )
 
+// EventTimeType is a start or end as the API expects it. Exactly one of
+// Date and DateTime is set, the other is sent as null so a PATCH can turn a
+// timed event into an all-day one and back.
+type EventTimeType struct {
+	Date     *string `json:"date"`
+	DateTime *string `json:"dateTime"`
+	TimeZone string  `json:"timeZone,omitempty"`
+}
+
 type PostEventType struct {
-	Summary  string `json:"summary"`
-	Location string `json:"location,omitempty"`
-	Start    struct {
-		DateTime string `json:"dateTime"`
-	} `json:"start"`
-	End struct {
-		DateTime string `json:"dateTime"`
-	} `json:"end"`
+	Summary                 string              `json:"summary"`
+	Location                string              `json:"location,omitempty"`
+	Description             string              `json:"description,omitempty"`
+	Start                   EventTimeType       `json:"start"`
+	End                     EventTimeType       `json:"end"`
+	Recurrence              []string            `json:"recurrence,omitempty"`
+	Attendees               []AttendeeType      `json:"attendees,omitempty"`
+	GuestsCanModify         bool                `json:"guestsCanModify"`
+	GuestsCanInviteOthers   bool                `json:"guestsCanInviteOthers"`
+	GuestsCanSeeOtherGuests bool                `json:"guestsCanSeeOtherGuests"`
+	ConferenceData          *ConferenceDataType `json:"conferenceData,omitempty"`
+	Reminders               *RemindersType      `json:"reminders,omitempty"`
 }
 
+// PatchEventType is the body of an update. The location and description
+// are always sent, an empty one clears it, which undo relies on too.
 type PatchEventType struct {
-	Summary  string `json:"summary"`
-	Location string `json:"location,omitempty"`
-	Start    struct {
-		DateTime string `json:"dateTime"`
-	} `json:"start"`
-	End struct {
-		DateTime string `json:"dateTime"`
-	} `json:"end"`
+	Summary                 string              `json:"summary"`
+	Location                string              `json:"location"`
+	Description             string              `json:"description"`
+	Start                   EventTimeType       `json:"start"`
+	End                     EventTimeType       `json:"end"`
+	Recurrence              []string            `json:"recurrence,omitempty"`
+	Attendees               []AttendeeType      `json:"attendees"`
+	GuestsCanModify         bool                `json:"guestsCanModify"`
+	GuestsCanInviteOthers   bool                `json:"guestsCanInviteOthers"`
+	GuestsCanSeeOtherGuests bool                `json:"guestsCanSeeOtherGuests"`
+	ConferenceData          *ConferenceDataType `json:"conferenceData,omitempty"`
+	Reminders               *RemindersType      `json:"reminders,omitempty"`
+}
+
+type RemindersType struct {
+	UseDefault bool       `json:"useDefault"`
+	Overrides  []Reminder `json:"overrides,omitempty"`
+}
+
+// NewReminders is the reminders block for event. An event without its own
+// reminders goes back to the calendar's defaults.
+func NewReminders(event Event) *RemindersType {
+	if !event.CustomReminders {
+		return &RemindersType{UseDefault: true}
+	}
+	return &RemindersType{Overrides: event.Reminders}
+}
+
+// ConferenceDataType asks Google to create a Meet link for the event,
+// which only happens when conferenceDataVersion=1 is also sent.
+type ConferenceDataType struct {
+	CreateRequest struct {
+		RequestId             string `json:"requestId"`
+		ConferenceSolutionKey struct {
+			Type string `json:"type"`
+		} `json:"conferenceSolutionKey"`
+	} `json:"createRequest"`
+}
+
+func NewConferenceRequest() *ConferenceDataType {
+	var conference ConferenceDataType
+	conference.CreateRequest.RequestId = fmt.Sprintf("go-home-%d", time.Now().UnixNano())
+	conference.CreateRequest.ConferenceSolutionKey.Type = "hangoutsMeet"
+	return &conference
+}
+
+// writeQuery is the query string for a create, update or delete of event.
+func writeQuery(event Event) string {
+	q := url.Values{}
+	if event.SendUpdates != "" {
+		q.Set("sendUpdates", event.SendUpdates)
+	}
+	if event.AddConference {
+		q.Set("conferenceDataVersion", "1")
+	}
+	if len(q) == 0 {
+		return ""
+	}
+	return "?" + q.Encode()
+}
+
+type AttendeeType struct {
+	Email          string `json:"email"`
+	DisplayName    string `json:"displayName,omitempty"`
+	Optional       bool   `json:"optional,omitempty"`
+	ResponseStatus string `json:"responseStatus,omitempty"`
+	Comment        string `json:"comment,omitempty"`
+}
+
+// RespondEventType replaces the guest list, which is how the API lets a
+// guest change their own response.
+type RespondEventType struct {
+	Attendees []AttendeeType `json:"attendees"`
+}
+
+func NewAttendees(attendees []Attendee) []AttendeeType {
+	result := []AttendeeType{}
+	for _, attendee := range attendees {
+		result = append(result, AttendeeType{
+			Email:          attendee.Email,
+			DisplayName:    attendee.DisplayName,
+			Optional:       attendee.Optional,
+			ResponseStatus: attendee.ResponseStatus,
+			Comment:        attendee.Comment,
+		})
+	}
+	return result
 }
 
-func PostEvent(event Event, config apiConfig) error {
-	url := fmt.Sprintf("https://www.googleapis.com/calendar/v3/calendars/%s/events", config.calendarID)
+func NewEventTime(t time.Time, allDay bool) EventTimeType {
+	var eventTime EventTimeType
+	if allDay {
+		date := t.In(CalendarLocation()).Format(time.DateOnly)
+		eventTime.Date = &date
+		return eventTime
+	}
+	dateTime := t.Format(time.RFC3339)
+	eventTime.DateTime = &dateTime
+	eventTime.TimeZone = ZoneName()
+	return eventTime
+}
+
+func eventsEndpoint(calendarID string) string {
+	return fmt.Sprintf("https://www.googleapis.com/calendar/v3/calendars/%s/events", url.PathEscape(calendarID))
+}
+func eventEndpoint(calendarID string, eventID string) string {
+	return eventsEndpoint(calendarID) + "/" + url.PathEscape(eventID)
+}
+
+// eventCalendarID is the calendar an event belongs to, new events without
+// one go to the default calendar.
+func eventCalendarID(event Event, config apiConfig) string {
+	if event.CalendarID != "" {
+		return event.CalendarID
+	}
+	return config.calendarID
+}
+func PostEvent(event Event, config apiConfig) (Event, error) {
+	calendarID := eventCalendarID(event, config)
+	url := eventsEndpoint(calendarID)
 
 	var postEvent PostEventType
 	postEvent.Summary = event.Summary
 	postEvent.Location = event.Location
-	postEvent.Start.DateTime = event.Start.DateTime.Format(time.RFC3339)
-	postEvent.End.DateTime = event.End.DateTime.Format(time.RFC3339)
+	postEvent.Description = event.Description
+	postEvent.Start = NewEventTime(event.Start.DateTime, event.AllDay)
+	postEvent.End = NewEventTime(event.End.DateTime, event.AllDay)
+	postEvent.Recurrence = event.Recurrence
+	postEvent.Attendees = NewAttendees(event.Attendees)
+	postEvent.GuestsCanModify = event.GuestsCanModify
+	postEvent.GuestsCanInviteOthers = event.GuestsCanInviteOthers
+	postEvent.GuestsCanSeeOtherGuests = event.GuestsCanSeeOtherGuests
+	if event.AddConference {
+		postEvent.ConferenceData = NewConferenceRequest()
+	}
+	if event.CustomReminders {
+		postEvent.Reminders = NewReminders(event)
+	}
 
 	payload, err := json.Marshal(postEvent)
 	if err != nil {
 		log.Printf("POST /calendar/events Error marshaling event %v\n", err)
-		return err
+		return Event{}, err
 	}
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(payload))
+	req, err := http.NewRequest("POST", url+writeQuery(event), bytes.NewBuffer(payload))
 	if err != nil {
 		log.Printf("POST /calendar/events Error creating new req %v\n", err)
-		return err
+		return Event{}, err
 	}
 	req.Header.Set("Authorization", config.accessToken)
+	req.Header.Set("Content-Type", "application/json")
 	res, err := http.DefaultClient.Do(req)
 	if err != nil {
 		log.Printf("POST /calendar/events Error making request %v\n", err)
-		return err
+		return Event{}, err
 	}
 	defer res.Body.Close()
-	return nil
-}
-func GetEvents(config apiConfig) ([]Event, error) {
-	url := fmt.Sprintf("https://www.googleapis.com/calendar/v3/calendars/%s/events", config.calendarID)
-	req, err := http.NewRequest("GET", url, nil)
+
+	body, err := io.ReadAll(res.Body)
 	if err != nil {
-		log.Printf("GET /calendar/events Error creating new req %v\n", err)
-		return nil, err
+		log.Printf("POST /calendar/events Error reading body %v\n", err)
+		return Event{}, err
+	}
+	if res.StatusCode != http.StatusOK {
+		log.Printf("POST /calendar/events Error failed with status code %v\n with body %v\n", res.StatusCode, string(body))
+		return Event{}, &StatusError{Request: "POST /calendar/events", Code: res.StatusCode}
 	}
-	req.Header.Set("Authorization", config.accessToken)
 
-	var timeMax string
-	timeMax = time.Now().AddDate(0, 0, 7).UTC().Format(time.RFC3339)
+	var item CalendarItem
+	err = json.Unmarshal(body, &item)
+	if err != nil {
+		log.Printf("POST /calendar/events Error unmarshaling body %v\n", err)
+		return Event{}, err
+	}
+	created, ok, err := ParseCalendarItem(item)
+	if err != nil {
+		return Event{}, err
+	}
+	if !ok {
+		return event, nil
+	}
+	created.CalendarID = calendarID
+	return created, nil
+}
 
-	q := req.URL.Query()
-	q.Add("timeMin", time.Now().UTC().Format(time.RFC3339))
-	q.Add("timeMax", timeMax)
+var errSyncTokenExpired = errors.New("sync token expired")
+
+func GetEvents(config apiConfig, calendarID string, timeMin time.Time, timeMax time.Time) ([]Event, error) {
+	q := url.Values{}
+	q.Add("timeMin", timeMin.UTC().Format(time.RFC3339))
+	q.Add("timeMax", timeMax.UTC().Format(time.RFC3339))
 	q.Add("orderBy", "startTime")
 	q.Add("singleEvents", "true")
+
+	var events []Event
+	for {
+		calendarEvent, err := getEventsPage(config, calendarID, q)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range calendarEvent.Items {
+			event, ok, err := ParseCalendarItem(item)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				event.CalendarID = calendarID
+				events = append(events, event)
+			}
+		}
+		if calendarEvent.NextPageToken == "" {
+			break
+		}
+		q.Set("pageToken", calendarEvent.NextPageToken)
+	}
+
+	return events, nil
+}
+func getEventsPage(config apiConfig, calendarID string, q url.Values) (CalendarEvent, error) {
+	var calendarEvent CalendarEvent
+	endpoint := eventsEndpoint(calendarID)
+	req, err := http.NewRequest("GET", endpoint, nil)
+	if err != nil {
+		log.Printf("GET /calendar/events Error creating new req %v\n", err)
+		return calendarEvent, err
+	}
+	req.Header.Set("Authorization", config.accessToken)
 	req.URL.RawQuery = q.Encode()
 
 	res, err := http.DefaultClient.Do(req)
 	if err != nil {
 		log.Printf("GET /calendar/events Error fetching data %v\n", err)
-		return nil, err
+		return calendarEvent, err
 	}
 	defer res.Body.Close()
 
 	body, err := io.ReadAll(res.Body)
 	if err != nil {
 		log.Printf("GET /calendar/events Error reading body %v\n", err)
-		return nil, err
+		return calendarEvent, err
 	}
 
 	if res.StatusCode == http.StatusUnauthorized {
-		log.Printf("GET /calendar/events Error status Unauthorized %v\n", err)
-		return nil, err
+		log.Printf("GET /calendar/events Error status Unauthorized\n")
+		return calendarEvent, fmt.Errorf("GET /calendar/events unauthorized")
+	}
+
+	if res.StatusCode == http.StatusGone {
+		log.Printf("GET /calendar/events sync token expired, full sync required\n")
+		return calendarEvent, errSyncTokenExpired
 	}
 
 	if res.StatusCode != http.StatusOK {
 		log.Printf("GET /calendar/events Error failed with status code %v\n with body %v\n", res.StatusCode, string(body))
-		return nil, err
+		return calendarEvent, fmt.Errorf("GET /calendar/events failed with status code %d", res.StatusCode)
 	}
 
-	var calendarEvent CalendarEvent
 	err = json.Unmarshal(body, &calendarEvent)
 	if err != nil {
 		log.Printf("GET /calendar/events Error unmarshaling body %v\n", err)
-		return nil, err
+		return calendarEvent, err
 	}
+	return calendarEvent, nil
+}
 
-	var events []Event
-	for _, item := range calendarEvent.Items {
-		var parsedTimeStart, parsedTimeEnd time.Time
-		var err error
+// SyncEvents brings cache up to date. With a sync token only the changes
+// since the last sync are downloaded, otherwise the whole window is fetched.
+func SyncEvents(config apiConfig, cache *EventCache, calendarID string, timeMin time.Time, timeMax time.Time) error {
+	syncToken := cache.SyncTokenFor(calendarID, timeMin, timeMax)
+	if syncToken != "" {
+		err := syncEventsFrom(config, cache, calendarID, url.Values{"syncToken": {syncToken}, "singleEvents": {"true"}}, false)
+		if err == nil {
+			return cache.Save()
+		}
+		if err != errSyncTokenExpired {
+			return err
+		}
+	}
 
-		if item.Start.DateTime != "" {
-			parsedTimeStart, err = time.Parse(time.RFC3339, item.Start.DateTime)
-			if err != nil {
-				log.Printf("GET /calendar/events Error parsing time %v\n", err)
-				return nil, err
+	syncMin := timeMin.Add(-syncPadding)
+	syncMax := timeMax.Add(syncPadding)
+	q := url.Values{}
+	q.Add("timeMin", syncMin.UTC().Format(time.RFC3339))
+	q.Add("timeMax", syncMax.UTC().Format(time.RFC3339))
+	q.Add("singleEvents", "true")
+	err := syncEventsFrom(config, cache, calendarID, q, true)
+	if err != nil {
+		return err
+	}
+	cache.SetWindow(calendarID, syncMin, syncMax)
+	return cache.Save()
+}
+func syncEventsFrom(config apiConfig, cache *EventCache, calendarID string, q url.Values, full bool) error {
+	var changed []Event
+	var removed []string
+	var calendarEvent CalendarEvent
+	var err error
+	for {
+		calendarEvent, err = getEventsPage(config, calendarID, q)
+		if err != nil {
+			return err
+		}
+		for _, item := range calendarEvent.Items {
+			if item.Status == "cancelled" {
+				removed = append(removed, item.ID)
+				continue
 			}
-		} else if item.Start.Date != "" {
-			parsedTimeStart, err = time.Parse("2006-01-02", item.Start.Date)
+			event, ok, err := ParseCalendarItem(item)
 			if err != nil {
-				log.Printf("GET /calendar/events Error parsing date %v\n", err)
-				return nil, err
+				return err
+			}
+			if ok {
+				event.CalendarID = calendarID
+				changed = append(changed, event)
 			}
-		} else {
-			log.Printf("GET /calendar/events Error: no start time or date provided\n")
-			continue
 		}
+		if calendarEvent.NextPageToken == "" {
+			break
+		}
+		q.Set("pageToken", calendarEvent.NextPageToken)
+	}
+	if full {
+		cache.Reset(calendarID)
+	}
+	cache.Apply(calendarID, changed, removed)
+	cache.SetSyncToken(calendarID, calendarEvent.NextSyncToken)
+	cache.SetTimeZone(calendarID, calendarEvent.TimeZone)
+	return nil
+}
 
-		if item.End.DateTime != "" {
-			parsedTimeEnd, err = time.Parse(time.RFC3339, item.End.DateTime)
-			if err != nil {
-				log.Printf("GET /calendar/events Error parsing time %v\n", err)
-				return nil, err
-			}
-		} else if item.End.Date != "" {
-			// All-day events use date format (YYYY-MM-DD)
-			parsedTimeEnd, err = time.Parse("2006-01-02", item.End.Date)
-			if err != nil {
-				log.Printf("GET /calendar/events Error parsing date %v\n", err)
-				return nil, err
-			}
-		} else {
-			log.Printf("GET /calendar/events Error: no end time or date provided\n")
-			continue
+// RespondToEvent sends our response, which is already set on the self
+// attendee of event, and lets the organizer know.
+func RespondToEvent(event Event, config apiConfig) (Event, error) {
+	payload, err := json.Marshal(RespondEventType{Attendees: NewAttendees(event.Attendees)})
+	if err != nil {
+		log.Printf("PATCH /calendar/events/rsvp Error marshaling event %v\n", err)
+		return Event{}, err
+	}
+	calendarID := eventCalendarID(event, config)
+	url := eventEndpoint(calendarID, event.Id) + "?sendUpdates=all"
+
+	req, err := http.NewRequest(http.MethodPatch, url, bytes.NewBuffer(payload))
+	if err != nil {
+		log.Printf("PATCH /calendar/events/rsvp Error creating new req %v\n", err)
+		return Event{}, err
+	}
+	req.Header.Set("Authorization", config.accessToken)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		log.Printf("PATCH /calendar/events/rsvp Error making request %v\n", err)
+		return Event{}, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		log.Printf("PATCH /calendar/events/rsvp Error reading body %v\n", err)
+		return Event{}, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		log.Printf("PATCH /calendar/events/rsvp Error failed with status code %v\n with body %v\n", resp.StatusCode, string(body))
+		return Event{}, &StatusError{Request: "PATCH /calendar/events/rsvp", Code: resp.StatusCode}
+	}
+
+	var item CalendarItem
+	err = json.Unmarshal(body, &item)
+	if err != nil {
+		log.Printf("PATCH /calendar/events/rsvp Error unmarshaling body %v\n", err)
+		return Event{}, err
+	}
+	updated, ok, err := ParseCalendarItem(item)
+	if err != nil {
+		return Event{}, err
+	}
+	if !ok {
+		return event, nil
+	}
+	updated.CalendarID = calendarID
+	return updated, nil
+}
+
+func GetEvent(config apiConfig, calendarID string, eventID string) (Event, error) {
+	req, err := http.NewRequest("GET", eventEndpoint(calendarID, eventID), nil)
+	if err != nil {
+		log.Printf("GET /calendar/events/id Error creating new req %v\n", err)
+		return Event{}, err
+	}
+	req.Header.Set("Authorization", config.accessToken)
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		log.Printf("GET /calendar/events/id Error fetching data %v\n", err)
+		return Event{}, err
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		log.Printf("GET /calendar/events/id Error reading body %v\n", err)
+		return Event{}, err
+	}
+	if res.StatusCode != http.StatusOK {
+		log.Printf("GET /calendar/events/id Error failed with status code %v\n with body %v\n", res.StatusCode, string(body))
+		if res.StatusCode == http.StatusNotFound || res.StatusCode == http.StatusGone {
+			return Event{}, fmt.Errorf("%w: %q", ErrEventNotFound, eventID)
 		}
+		return Event{}, fmt.Errorf("GET /calendar/events/id failed with status code %d", res.StatusCode)
+	}
 
-		_, startZone := parsedTimeStart.Zone()
-		_, endZone := parsedTimeEnd.Zone()
-		events = append(events, Event{
-			Id:      item.ID,
-			Summary: item.Summary,
-			Start: DateTime{
-				DateTime: parsedTimeStart,
-				Date:     parsedTimeStart.Format(time.DateOnly),
-				TimeZone: startZone,
-			},
-			End: DateTime{
-				DateTime: parsedTimeEnd,
-				Date:     parsedTimeEnd.Format(time.DateOnly),
-				TimeZone: endZone,
-			},
-			Location: item.Location,
+	var item CalendarItem
+	err = json.Unmarshal(body, &item)
+	if err != nil {
+		log.Printf("GET /calendar/events/id Error unmarshaling body %v\n", err)
+		return Event{}, err
+	}
+	event, ok, err := ParseCalendarItem(item)
+	if err != nil {
+		return Event{}, err
+	}
+	if !ok {
+		// Cancelled events come back without a start or end
+		return Event{}, fmt.Errorf("%w: %q", ErrEventNotFound, eventID)
+	}
+	event.CalendarID = calendarID
+	return event, nil
+}
+func ParseCalendarItem(item CalendarItem) (Event, bool, error) {
+	var parsedTimeStart, parsedTimeEnd time.Time
+	var err error
+
+	if item.Start.DateTime != "" {
+		parsedTimeStart, err = time.Parse(time.RFC3339, item.Start.DateTime)
+		if err != nil {
+			log.Printf("GET /calendar/events Error parsing time %v\n", err)
+			return Event{}, false, err
+		}
+	} else if item.Start.Date != "" {
+		parsedTimeStart, err = time.ParseInLocation("2006-01-02", item.Start.Date, CalendarLocation())
+		if err != nil {
+			log.Printf("GET /calendar/events Error parsing date %v\n", err)
+			return Event{}, false, err
+		}
+	} else {
+		log.Printf("GET /calendar/events Error: no start time or date provided\n")
+		return Event{}, false, nil
+	}
+
+	if item.End.DateTime != "" {
+		parsedTimeEnd, err = time.Parse(time.RFC3339, item.End.DateTime)
+		if err != nil {
+			log.Printf("GET /calendar/events Error parsing time %v\n", err)
+			return Event{}, false, err
+		}
+	} else if item.End.Date != "" {
+		// All-day events use date format (YYYY-MM-DD)
+		parsedTimeEnd, err = time.ParseInLocation("2006-01-02", item.End.Date, CalendarLocation())
+		if err != nil {
+			log.Printf("GET /calendar/events Error parsing date %v\n", err)
+			return Event{}, false, err
+		}
+	} else {
+		log.Printf("GET /calendar/events Error: no end time or date provided\n")
+		return Event{}, false, nil
+	}
+
+	var attendees []Attendee
+	for _, attendee := range item.Attendees {
+		attendees = append(attendees, Attendee{
+			Email:          attendee.Email,
+			DisplayName:    attendee.DisplayName,
+			Organizer:      attendee.Organizer,
+			Self:           attendee.Self,
+			Optional:       attendee.Optional,
+			ResponseStatus: attendee.ResponseStatus,
+			Comment:        attendee.Comment,
 		})
 	}
+	var entryPoints []EntryPoint
+	for _, entry := range item.ConferenceData.EntryPoints {
+		entryPoints = append(entryPoints, EntryPoint{Type: entry.EntryPointType, URI: entry.URI, Label: entry.Label})
+	}
 
-	return events, nil
+	guestsCanInviteOthers := item.GuestsCanInviteOthers == nil || *item.GuestsCanInviteOthers
+	guestsCanSeeOtherGuests := item.GuestsCanSeeOtherGuests == nil || *item.GuestsCanSeeOtherGuests
+
+	_, startZone := parsedTimeStart.Zone()
+	_, endZone := parsedTimeEnd.Zone()
+	return Event{
+		Id:               item.ID,
+		Etag:             item.Etag,
+		Summary:          item.Summary,
+		AllDay:           item.Start.DateTime == "",
+		Recurrence:       item.Recurrence,
+		RecurringEventId: item.RecurringEventID,
+		Start: DateTime{
+			DateTime: parsedTimeStart,
+			Date:     parsedTimeStart.Format(time.DateOnly),
+			TimeZone: startZone,
+		},
+		End: DateTime{
+			DateTime: parsedTimeEnd,
+			Date:     parsedTimeEnd.Format(time.DateOnly),
+			TimeZone: endZone,
+		},
+		Location:    item.Location,
+		Description: item.Description,
+		HTMLLink:    item.HTMLLink,
+		Organizer: Person{
+			Email:       item.Organizer.Email,
+			DisplayName: item.Organizer.DisplayName,
+			Self:        item.Organizer.Self,
+		},
+		Attendees: attendees,
+		Conference: Conference{
+			Name:        item.ConferenceData.ConferenceSolution.Name,
+			EntryPoints: entryPoints,
+		},
+		Source:                  Source{URL: item.Source.URL, Title: item.Source.Title},
+		GuestsCanModify:         item.GuestsCanModify,
+		GuestsCanInviteOthers:   guestsCanInviteOthers,
+		GuestsCanSeeOtherGuests: guestsCanSeeOtherGuests,
+		Reminders:               item.Reminders.Overrides,
+		CustomReminders:         !item.Reminders.UseDefault,
+	}, true, nil
 }
 func DeleteEvent(event Event, config apiConfig) error {
 	client := http.Client{}
-	url := fmt.Sprintf("https://www.googleapis.com/calendar/v3/calendars/%s/events/%s", config.calendarID, event.Id)
+	url := eventEndpoint(eventCalendarID(event, config), event.Id) + writeQuery(event)
 
 	req, err := http.NewRequest(http.MethodDelete, url, nil)
 	if err != nil {
-		log.Fatal(err)
+		log.Printf("DELETE /calendar/events Error creating new req %v\n", err)
+		return err
 	}
 
 	req.Header.Set("Authorization", config.accessToken)
 	resp, err := client.Do(req)
 	if err != nil {
-		log.Fatal(err)
+		log.Printf("DELETE /calendar/events Error making request %v\n", err)
+		return err
 	}
 
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusNoContent {
 		log.Printf("DELETE /calendar/events Error failed with status code %v\n", resp.StatusCode)
-		return err
+		return &StatusError{Request: "DELETE /calendar/events", Code: resp.StatusCode}
 	}
 	return nil
 }
-func UpdateEvent(event Event, config apiConfig) error {
+
+// MoveEvent moves event to another calendar, keeping its id. Occurrences
+// of a recurring event cannot be moved on their own.
+func MoveEvent(event Event, destination string, config apiConfig) (Event, error) {
+	q := url.Values{}
+	q.Set("destination", destination)
+	if event.SendUpdates != "" {
+		q.Set("sendUpdates", event.SendUpdates)
+	}
+	req, err := http.NewRequest("POST", eventEndpoint(eventCalendarID(event, config), event.Id)+"/move?"+q.Encode(), nil)
+	if err != nil {
+		log.Printf("POST /calendar/events/move Error creating new req %v\n", err)
+		return Event{}, err
+	}
+	req.Header.Set("Authorization", config.accessToken)
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		log.Printf("POST /calendar/events/move Error making request %v\n", err)
+		return Event{}, err
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		log.Printf("POST /calendar/events/move Error reading body %v\n", err)
+		return Event{}, err
+	}
+	if res.StatusCode != http.StatusOK {
+		log.Printf("POST /calendar/events/move Error failed with status code %v\n with body %v\n", res.StatusCode, string(body))
+		return Event{}, &StatusError{Request: "POST /calendar/events/move", Code: res.StatusCode}
+	}
+
+	var item CalendarItem
+	err = json.Unmarshal(body, &item)
+	if err != nil {
+		log.Printf("POST /calendar/events/move Error unmarshaling body %v\n", err)
+		return Event{}, err
+	}
+	moved, ok, err := ParseCalendarItem(item)
+	if err != nil {
+		return Event{}, err
+	}
+	if !ok {
+		return Event{}, fmt.Errorf("%w: %q", ErrEventNotFound, event.Id)
+	}
+	moved.CalendarID = destination
+	return moved, nil
+}
+
+// NewPatchEvent is the update that makes the event on the calendar match
+// event.
+func NewPatchEvent(event Event) PatchEventType {
 	var patchEvent PatchEventType
 	patchEvent.Summary = event.Summary
 	patchEvent.Location = event.Location
-	patchEvent.Start.DateTime = event.Start.DateTime.Format(time.RFC3339)
-	patchEvent.End.DateTime = event.End.DateTime.Format(time.RFC3339)
+	patchEvent.Description = event.Description
+	patchEvent.Start = NewEventTime(event.Start.DateTime, event.AllDay)
+	patchEvent.End = NewEventTime(event.End.DateTime, event.AllDay)
+	patchEvent.Recurrence = event.Recurrence
+	patchEvent.Attendees = NewAttendees(event.Attendees)
+	patchEvent.GuestsCanModify = event.GuestsCanModify
+	patchEvent.GuestsCanInviteOthers = event.GuestsCanInviteOthers
+	patchEvent.GuestsCanSeeOtherGuests = event.GuestsCanSeeOtherGuests
+	if event.AddConference {
+		patchEvent.ConferenceData = NewConferenceRequest()
+	}
+	patchEvent.Reminders = NewReminders(event)
+	return patchEvent
+}
 
-	payload, err := json.Marshal(patchEvent)
+func UpdateEvent(event Event, config apiConfig) (Event, error) {
+	payload, err := json.Marshal(NewPatchEvent(event))
 	if err != nil {
-		log.Fatal(err)
+		log.Printf("PATCH /calendar/events Error marshaling event %v\n", err)
+		return Event{}, err
 	}
 	client := http.Client{}
-	url := fmt.Sprintf("https://www.googleapis.com/calendar/v3/calendars/%s/events/%s", config.calendarID, event.Id)
+	calendarID := eventCalendarID(event, config)
+	url := eventEndpoint(calendarID, event.Id) + writeQuery(event)
 
 	req, err := http.NewRequest(http.MethodPatch, url, bytes.NewBuffer(payload))
 	if err != nil {
-		log.Fatal(err)
+		log.Printf("PATCH /calendar/events Error creating new req %v\n", err)
+		return Event{}, err
 	}
 	req.Header.Set("Authorization", config.accessToken)
+	req.Header.Set("Content-Type", "application/json")
 	resp, err := client.Do(req)
 	if err != nil {
-		log.Fatal(err)
+		log.Printf("PATCH /calendar/events Error making request %v\n", err)
+		return Event{}, err
 	}
 
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		log.Fatal(err)
+		log.Printf("PATCH /calendar/events Error reading body %v\n", err)
+		return Event{}, err
 	}
 
 	if resp.StatusCode != http.StatusOK {
 		log.Printf("PATCH /calendar/events Error failed with status code %v\n with body %v\n", resp.StatusCode, string(body))
-		return err
+		return Event{}, &StatusError{Request: "PATCH /calendar/events", Code: resp.StatusCode}
 	}
 
-	return nil
+	var item CalendarItem
+	err = json.Unmarshal(body, &item)
+	if err != nil {
+		log.Printf("PATCH /calendar/events Error unmarshaling body %v\n", err)
+		return Event{}, err
+	}
+	updated, ok, err := ParseCalendarItem(item)
+	if err != nil {
+		return Event{}, err
+	}
+	if !ok {
+		return event, nil
+	}
+	updated.CalendarID = calendarID
+	return updated, nil
 }
-func RefreshOauth(config apiConfig) error {
+func RefreshOauth(config *apiConfig) error {
 	tokenURL := "https://oauth2.googleapis.com/token"
 	clientID := os.Getenv("CLIENT_ID")
 	clientSecret := os.Getenv("CLIENT_SECRET")
@@ -276,7 +756,7 @@ func RefreshOauth(config apiConfig) error {
 
 	if resp.StatusCode != http.StatusOK {
 		log.Printf("POST /auth/refresh token refresh failed with status %d: %s\n", resp.StatusCode, string(body))
-		return err
+		return fmt.Errorf("POST /auth/refresh failed with status code %d", resp.StatusCode)
 	}
 
 	var tokenResp TokenResponse
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type freeBusyMsg struct {
+	text string
+	busy []TimeRange
+	err  error
+}
+
+func freeBusyCmd(backend CalendarBackend, text string, query FindTimeQuery) tea.Cmd {
+	timeMin, timeMax := query.Bounds()
+	return func() tea.Msg {
+		busy, err := backend.FreeBusy(timeMin, timeMax)
+		return freeBusyMsg{text: text, busy: busy, err: err}
+	}
+}
+
+// openFindTime shows the prompt for looking up free time.
+func (m *Model) openFindTime() tea.Cmd {
+	m.mode = findTime
+	m.findInput = textinput.New()
+	m.findInput.Placeholder = "1h mon-fri 9-17"
+	m.findInput.Cursor.Style = style.cursorStyle
+	m.findInput.PromptStyle = style.focusedStyle
+	m.findInput.TextStyle = style.focusedStyle
+	m.findText, m.findSlots, m.findIdx, m.findErr, m.finding = "", nil, 0, nil, false
+	return m.findInput.Focus()
+}
+
+// searchFreeTime looks for the query in the input. Days already on screen
+// are worked out from the loaded events, others are asked of the calendar.
+func (m *Model) searchFreeTime() tea.Cmd {
+	query, err := ParseFindTime(m.findInput.Value())
+	m.findText, m.findSlots, m.findIdx, m.findErr = m.findInput.Value(), nil, 0, err
+	if err != nil {
+		return nil
+	}
+	m.findQuery = query
+	timeMin, timeMax := query.Bounds()
+	windowMin, windowMax := CurrentWindow()
+	if !m.fetching && !timeMin.Before(windowMin) && !timeMax.After(windowMax) {
+		m.findSlots = FreeSlots(query, BusyFromEvents(m.loaded), Now())
+		return nil
+	}
+	m.finding = true
+	return freeBusyCmd(m.backend, m.findText, query)
+}
+
+// openFreeSlot opens the form for a new event at the start of the picked
+// slot.
+func (m *Model) openFreeSlot() {
+	slot := m.findSlots[m.findIdx]
+	m.openForm(Event{Summary: "+"}, DaysBetween(WindowStart(), slot.Start))
+	start := slot.Start.In(CalendarLocation())
+	end := start.Add(m.findQuery.Duration)
+	m.inputs[Date].SetValue(start.Format(time.DateOnly))
+	m.inputs[StartTime].SetValue(start.Format("15:04"))
+	m.inputs[EndDate].SetValue(end.Format(time.DateOnly))
+	m.inputs[EndTime].SetValue(end.Format("15:04"))
+}
+
+func (m Model) updateFindTime(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case freeBusyMsg:
+		if msg.text != m.findText {
+			return m, nil
+		}
+		m.finding = false
+		if msg.err != nil && !errors.Is(msg.err, ErrOffline) {
+			m.findErr = msg.err
+			return m, nil
+		}
+		// Offline the busy times come from the cached events
+		m.findSlots = FreeSlots(m.findQuery, msg.busy, Now())
+		return m, nil
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "ctrl+c":
+			return m, tea.Quit
+		case "esc":
+			m.mode = calendar
+			return m, nil
+		case "up", "ctrl+p":
+			if m.findIdx > 0 {
+				m.findIdx--
+			}
+			return m, nil
+		case "down", "ctrl+n":
+			if m.findIdx < len(m.findSlots)-1 {
+				m.findIdx++
+			}
+			return m, nil
+		case "enter":
+			if m.finding {
+				return m, nil
+			}
+			if m.findInput.Value() == m.findText && len(m.findSlots) > 0 {
+				m.openFreeSlot()
+				return m, nil
+			}
+			return m, m.searchFreeTime()
+		}
+		var cmd tea.Cmd
+		m.findInput, cmd = m.findInput.Update(msg)
+		if m.findInput.Value() != m.findText {
+			m.findSlots, m.findErr = nil, nil
+		}
+		return m, cmd
+	}
+	var cmds [2]tea.Cmd
+	m.findInput, cmds[0] = m.findInput.Update(msg)
+	m.spinner, cmds[1] = m.spinner.Update(msg)
+	return m, tea.Batch(cmds[:]...)
+}
+
+func (m Model) findTimeView() string {
+	s := "\nFind time (enter to search, ↑/↓ and enter to create an event, esc to cancel)\n\n"
+	s += m.findInput.View() + "\n\n"
+	switch {
+	case m.findErr != nil:
+		return s + style.warningStyle.Render(m.findErr.Error()) + "\n"
+	case m.finding:
+		return s + "Looking " + m.spinner.View() + "\n"
+	case m.findText == "" || m.findInput.Value() != m.findText:
+		return s + style.grayBlurredStyle.Render("a length, hours and days, e.g. 30m, 1h tomorrow, 45m 9-12 mon-fri") + "\n"
+	}
+
+	query := m.findQuery
+	today := StartOfDay(Now())
+	days := query.First.Format("Mon 2 Jan")
+	if !query.Last.Equal(query.First) {
+		days += " - " + query.Last.Format("Mon 2 Jan")
+	}
+	s += style.grayBlurredStyle.Render(fmt.Sprintf("%s between %s and %s, %s", FormatDuration(query.Duration),
+		atMinutes(today, query.From).Format("15:04"), atMinutes(today, query.To).Format("15:04"), days)) + "\n\n"
+	if len(m.findSlots) == 0 {
+		return s + style.warningStyle.Render("No free time found") + "\n"
+	}
+
+	// Only the slots around the cursor when they do not all fit
+	first, last := 0, len(m.findSlots)
+	if height := m.height - 12; height > 0 && last > height {
+		first = max(min(m.findIdx-height/2, last-height), 0)
+		last = first + height
+	}
+	for i := first; i < last; i++ {
+		slot := m.findSlots[i]
+		start, end := slot.Start.In(CalendarLocation()), slot.End.In(CalendarLocation())
+		line := fmt.Sprintf("%s  %s-%s  %s free", start.Format("Mon 2 Jan"), start.Format("15:04"), end.Format("15:04"),
+			FormatDuration(end.Sub(start)))
+		if i == m.findIdx {
+			s += style.focusedStyle.Render("› "+line) + "\n"
+		} else {
+			s += "  " + line + "\n"
+		}
+	}
+	return s
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"strings"
+	"time"
+)
+
+// newEventStart is when an event added on a day starts: when working hours
+// begin, or the next half hour when that has passed today.
+func newEventStart(day int) time.Time {
+	start := atMinutes(WindowStart().AddDate(0, 0, day), workStart)
+	now := Now()
+	next := atMinutes(now, (now.Hour()*60+now.Minute())/slotMinutes*slotMinutes+slotMinutes)
+	if next.After(start) && next.Format(time.DateOnly) == start.Format(time.DateOnly) {
+		return next
+	}
+	return start
+}
+
+// openForm fills the event form from event. Cards without an id, like the
+// "+" cards, open an empty form for a new event on day.
+func (m *Model) openForm(event Event, day int) {
+	loc := CalendarLocation()
+	m.mode = forms
+	m.focusIndex = 0
+	m.formEvent = event
+	m.newEvent = event.Id == ""
+	if event.Summary == "+" {
+		m.inputs[Summary].SetValue("")
+	} else {
+		m.inputs[Summary].SetValue(event.Summary)
+	}
+	if event.Start.Date == "" {
+		m.inputs[Date].SetValue(NewEventDate(day))
+		m.inputs[EndDate].SetValue(NewEventDate(day))
+	} else {
+		m.inputs[Date].SetValue(EventDate(event))
+		m.inputs[EndDate].SetValue(EventEndDate(event))
+	}
+	if event.Start.DateTime.IsZero() {
+		// The "+" card has no time yet
+		start := newEventStart(day)
+		end := start.Add(defaultDuration)
+		m.inputs[StartTime].SetValue(start.Format("15:04"))
+		m.inputs[EndDate].SetValue(end.Format(time.DateOnly))
+		m.inputs[EndTime].SetValue(end.Format("15:04"))
+	} else {
+		m.inputs[StartTime].SetValue(event.Start.DateTime.In(loc).Format("15:04"))
+		m.inputs[EndTime].SetValue(event.End.DateTime.In(loc).Format("15:04"))
+	}
+	if event.AllDay {
+		m.inputs[AllDay].SetValue("y")
+	} else {
+		m.inputs[AllDay].SetValue("n")
+	}
+	m.inputs[Location].SetValue(event.Location)
+	m.inputs[Repeat].SetValue(DescribeRecurrence(event.Recurrence))
+	m.inputs[Guests].SetValue(GuestList(event))
+	if m.newEvent {
+		m.inputs[Permissions].SetValue("invite,see")
+	} else {
+		m.inputs[Permissions].SetValue(GuestPermissions(event))
+	}
+	if ConferenceLink(event) != "" {
+		m.inputs[Video].SetValue("y")
+	} else {
+		m.inputs[Video].SetValue("n")
+	}
+	m.inputs[Notify].SetValue(notifyAll)
+	if event.CustomReminders {
+		m.inputs[Reminders].SetValue(DescribeReminders(event.Reminders))
+	} else {
+		m.inputs[Reminders].SetValue("default")
+	}
+	m.formSeries = Event{}
+	m.inputs[CalendarName].SetValue(m.calendarName(event))
+	m.inputs[Id].SetValue(event.Id)
+}
+
+// eventFromForm builds the event the form describes. It returns false and
+// marks the offending fields when the input is not usable.
+func (m *Model) eventFromForm() (Event, bool) {
+	if FormsValidation(m.inputs, &m.validFields, m.calendars) {
+		return Event{}, false
+	}
+	loc := CalendarLocation()
+
+	calendarID := ""
+	if selected, ok := FindCalendar(m.calendars, m.inputs[CalendarName].Value()); ok {
+		calendarID = selected.Id
+	}
+	if !m.newEvent && calendarID != "" && calendarID != m.formEvent.CalendarID {
+		// Moving an event between calendars is not supported from the form
+		m.validFields[CalendarName] = false
+		return Event{}, false
+	}
+
+	// Edits start from the original so fields the form does not show, like
+	// the description and guests, are kept
+	var event Event
+	if !m.newEvent {
+		event = m.formEvent
+		event.Recurrence = nil
+		event.Pending = false
+	}
+	event.Id = m.inputs[Id].Value()
+	event.Summary = m.inputs[Summary].Value()
+	event.Location = m.inputs[Location].Value()
+	event.CalendarID = calendarID
+	if !m.newEvent {
+		event.CalendarID = m.formEvent.CalendarID
+		event.RecurringEventId = m.formEvent.RecurringEventId
+	}
+
+	if IsYes(m.inputs[AllDay].Value()) {
+		start, _ := time.ParseInLocation("2006-01-02", m.inputs[Date].Value(), loc)
+		end, _ := time.ParseInLocation("2006-01-02", m.inputs[EndDate].Value(), loc)
+		event.AllDay = true
+		event.Start.DateTime = start
+		// The API treats the end date of all-day events as exclusive
+		event.End.DateTime = end.AddDate(0, 0, 1)
+	} else {
+		event.Start.DateTime, _ = time.ParseInLocation("2006-01-02 15:04", m.inputs[Date].Value()+" "+m.inputs[StartTime].Value(), loc)
+		event.End.DateTime, _ = time.ParseInLocation("2006-01-02 15:04", m.inputs[EndDate].Value()+" "+m.inputs[EndTime].Value(), loc)
+	}
+	if repeat := m.inputs[Repeat].Value(); strings.TrimSpace(repeat) != "" {
+		event.Recurrence, _ = ParseRepeat(repeat, event.AllDay)
+	}
+	emails, _ := ParseGuests(m.inputs[Guests].Value())
+	event.Attendees = MergeAttendees(event.Attendees, emails)
+	event.GuestsCanModify, event.GuestsCanInviteOthers, event.GuestsCanSeeOtherGuests, _ = ParseGuestPermissions(m.inputs[Permissions].Value())
+	event.AddConference = IsYes(m.inputs[Video].Value()) && ConferenceLink(event) == ""
+	event.SendUpdates, _ = ParseSendUpdates(m.inputs[Notify].Value())
+	event.Reminders, event.CustomReminders, _ = ParseReminders(m.inputs[Reminders].Value())
+	event.Start.Date = event.Start.DateTime.Format(time.DateOnly)
+	event.End.Date = event.End.DateTime.Format(time.DateOnly)
+	return event, true
+}
+
+func IsYes(value string) bool {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "y", "yes", "true":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const (
+	// nudgeStep is how far the move and resize keys change an event
+	nudgeStep = 15 * time.Minute
+	// moveDelay is how long after the last move key the event is saved, so
+	// a run of presses is saved at once
+	moveDelay = 600 * time.Millisecond
+)
+
+type moveSaveMsg struct {
+	seq int
+}
+
+// ResizeEvent moves the end of event by d, a day at a time for all-day
+// events. It never ends before it starts.
+func ResizeEvent(event Event, d time.Duration) (Event, error) {
+	end := event.End.DateTime.Add(d)
+	if event.AllDay {
+		days := 1
+		if d < 0 {
+			days = -1
+		}
+		end = event.End.DateTime.In(CalendarLocation()).AddDate(0, 0, days)
+	}
+	if !end.After(event.Start.DateTime) {
+		return event, fmt.Errorf("%q cannot get any shorter", event.Summary)
+	}
+	event.End.DateTime = end
+	event.End.Date = end.In(CalendarLocation()).Format(time.DateOnly)
+	return event, nil
+}
+
+// moveKey moves or resizes the event under the cursor. The change shows at
+// once and is saved when the keys stop.
+func (m *Model) moveKey(msg tea.KeyMsg) (tea.Cmd, bool) {
+	var change func(Event) (Event, error)
+	switch {
+	case key.Matches(msg, m.keys.Earlier):
+		change = func(event Event) (Event, error) { return nudgeEvent(event, -nudgeStep) }
+	case key.Matches(msg, m.keys.Later):
+		change = func(event Event) (Event, error) { return nudgeEvent(event, nudgeStep) }
+	case key.Matches(msg, m.keys.DayBefore):
+		change = func(event Event) (Event, error) { return ShiftEvent(event, -24*time.Hour) }
+	case key.Matches(msg, m.keys.DayAfter):
+		change = func(event Event) (Event, error) { return ShiftEvent(event, 24*time.Hour) }
+	case key.Matches(msg, m.keys.Longer):
+		change = func(event Event) (Event, error) { return ResizeEvent(event, nudgeStep) }
+	case key.Matches(msg, m.keys.Shorter):
+		change = func(event Event) (Event, error) { return ResizeEvent(event, -nudgeStep) }
+	case key.Matches(msg, m.keys.Duplicate):
+		return m.openDuplicate(), true
+	default:
+		return nil, false
+	}
+	event, _ := m.selection()
+	if event.Id == "" || event.Summary == "+" {
+		return nil, true
+	}
+	var cmd tea.Cmd
+	if m.moveAfter.Id != "" && m.moveAfter.Id != event.Id {
+		cmd = m.flushMove()
+	}
+	changed, err := change(event)
+	if err != nil {
+		m.notice = err.Error()
+		return cmd, true
+	}
+	if m.moveAfter.Id == "" {
+		m.moveBefore = event
+	}
+	m.moveAfter = changed
+	m.moveSeq++
+	m.showMove()
+	m.placeView(changed, DateToIndex(EventDate(changed)))
+	seq := m.moveSeq
+	return tea.Batch(cmd, tea.Tick(moveDelay, func(time.Time) tea.Msg {
+		return moveSaveMsg{seq: seq}
+	})), true
+}
+
+// nudgeEvent moves a timed event by d. All-day events only move by days.
+func nudgeEvent(event Event, d time.Duration) (Event, error) {
+	if event.AllDay {
+		return event, fmt.Errorf("all-day events move by whole days")
+	}
+	return ShiftEvent(event, d)
+}
+
+// showMove puts the moved event in place of the one loaded, until it is
+// saved and loaded again.
+func (m *Model) showMove() {
+	if m.moveAfter.Id == "" {
+		return
+	}
+	events := make([]Event, 0, len(m.loaded))
+	for _, event := range m.loaded {
+		if event.Id != m.moveAfter.Id {
+			events = append(events, event)
+		}
+	}
+	m.setEvents(append(events, m.moveAfter))
+}
+
+// flushMove saves the moved event right away.
+func (m *Model) flushMove() tea.Cmd {
+	if m.moveAfter.Id == "" {
+		return nil
+	}
+	before, after := m.moveBefore, m.moveAfter
+	m.moveBefore, m.moveAfter = Event{}, Event{}
+	return saveEventCmd(m.backend, opUpdate, before, after)
+}
+
+// openDuplicate asks which day to copy the event under the cursor to.
+func (m *Model) openDuplicate() tea.Cmd {
+	event, _ := m.selection()
+	if event.Id == "" || event.Summary == "+" {
+		return nil
+	}
+	m.mode = duplicate
+	m.dupEvent = event
+	m.dupErr = nil
+	m.dupInput = textinput.New()
+	m.dupInput.Placeholder = "tomorrow, fri, next mon, 2026-01-05"
+	m.dupInput.Cursor.Style = style.cursorStyle
+	return m.dupInput.Focus()
+}
+
+func (m Model) updateDuplicate(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch msg.String() {
+		case "ctrl+c":
+			return m, tea.Quit
+		case "esc":
+			m.mode = calendar
+			return m, nil
+		case "enter":
+			day, err := ParseDay(m.dupInput.Value())
+			if err != nil {
+				m.dupErr = err
+				return m, nil
+			}
+			m.mode = calendar
+			copied := CopyToDay(m.dupEvent, day)
+			// Show the copy under a stand-in id until it is saved
+			shown := copied
+			shown.Id = NewLocalId()
+			shown.Pending = true
+			m.setEvents(append(m.loaded, shown))
+			m.placeView(shown, DateToIndex(EventDate(shown)))
+			return m, saveEventCmd(m.backend, opCreate, Event{}, copied)
+		}
+	}
+	var cmd tea.Cmd
+	m.dupInput, cmd = m.dupInput.Update(msg)
+	m.dupErr = nil
+	return m, cmd
+}
+
+func (m Model) duplicateView() string {
+	s := fmt.Sprintf("\nDuplicate %q to\n\n", m.dupEvent.Summary) + m.dupInput.View() + "\n\n"
+	if m.dupErr != nil {
+		s += style.errorStyle.Render(m.dupErr.Error()) + "\n\n"
+	}
+	return s + style.grayBlurredStyle.Render("enter to duplicate, esc to cancel") + "\n"
+}
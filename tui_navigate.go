@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// showWindow pages the views to page with the cursor on day of it. The
+// cached events are shown at once and fresh ones are fetched behind them.
+func (m *Model) showWindow(page int, day int) tea.Cmd {
+	SetWindowPage(page)
+	var events []Event
+	if cached, ok := m.backend.(CachedBackend); ok {
+		timeMin, timeMax := CurrentWindow()
+		events = VisibleEvents(cached.CachedEvents(timeMin, timeMax))
+	}
+	m.setEvents(events)
+	m.placeView(Event{}, max(min(day, WindowDays()-1), 0))
+	m.fetching = true
+	return loadEventsCmd(m.backend)
+}
+
+// showDay pages the views to the window containing day.
+func (m *Model) showDay(day time.Time) tea.Cmd {
+	page := PageOf(day)
+	first := HomeWindowStart().AddDate(0, 0, page*WindowDays())
+	return m.showWindow(page, DaysBetween(first, day))
+}
+
+// navigate handles the keys that move the window or search, shared by
+// every view.
+func (m *Model) navigate(msg tea.KeyMsg) (tea.Cmd, bool) {
+	_, day := m.selection()
+	switch {
+	case key.Matches(msg, m.keys.Prev):
+		return m.showWindow(WindowPage()-1, day), true
+	case key.Matches(msg, m.keys.Next):
+		return m.showWindow(WindowPage()+1, day), true
+	case key.Matches(msg, m.keys.Today):
+		return m.showDay(Now()), true
+	case key.Matches(msg, m.keys.Search):
+		return m.openSearch(), true
+	case key.Matches(msg, m.keys.NextMatch):
+		m.nextMatch(true)
+		return nil, true
+	case key.Matches(msg, m.keys.PrevMatch):
+		m.nextMatch(false)
+		return nil, true
+	case key.Matches(msg, m.keys.Clear):
+		if m.filter != "" {
+			m.applyFilter("")
+		} else {
+			m.marked = make(map[string]Event)
+		}
+		return nil, true
+	case key.Matches(msg, m.keys.Mark):
+		m.toggleMark()
+		return nil, true
+	case key.Matches(msg, m.keys.Batch):
+		m.openBatch()
+		return nil, true
+	case key.Matches(msg, m.keys.Undo):
+		return m.undo(false), true
+	case key.Matches(msg, m.keys.Redo):
+		return m.undo(true), true
+	case key.Matches(msg, m.keys.Goto):
+		m.mode = gotoDate
+		m.gotoErr = nil
+		m.gotoInput = textinput.New()
+		m.gotoInput.Placeholder = "2026-01-05, tomorrow, fri, next mon, in 10 days"
+		m.gotoInput.Cursor.Style = style.cursorStyle
+		return m.gotoInput.Focus(), true
+	}
+	return nil, false
+}
+
+func (m Model) updateGotoDate(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch msg.String() {
+		case "ctrl+c":
+			return m, tea.Quit
+		case "esc":
+			m.mode = calendar
+			return m, nil
+		case "enter":
+			day, err := ParseDay(m.gotoInput.Value())
+			if err != nil {
+				m.gotoErr = err
+				return m, nil
+			}
+			m.mode = calendar
+			return m, m.showDay(day)
+		}
+	}
+	var cmd tea.Cmd
+	m.gotoInput, cmd = m.gotoInput.Update(msg)
+	m.gotoErr = nil
+	return m, cmd
+}
+
+func (m Model) gotoDateView() string {
+	s := "\nGo to date\n\n" + m.gotoInput.View() + "\n\n"
+	if m.gotoErr != nil {
+		s += style.errorStyle.Render(m.gotoErr.Error()) + "\n\n"
+	}
+	return s + style.grayBlurredStyle.Render("enter to go, esc to cancel") + "\n"
+}
+
+// windowTitleView is the line above every view naming the days shown.
+func (m Model) windowTitleView() string {
+	s := style.focusedStyle.Bold(true).Render(WindowTitle())
+	if m.fetching {
+		s += style.grayBlurredStyle.Render(" loading…")
+	}
+	if m.filter != "" && m.mode != search {
+		s += style.warningStyle.Render(fmt.Sprintf("  /%s %d of %d", m.filter, len(m.events), len(m.loaded)))
+	}
+	if len(m.marked) > 0 {
+		s += style.focusedStyle.Render(fmt.Sprintf("  %d marked, %s to act", len(m.marked), keyHint(m.keys.Batch)))
+	}
+	if TodayIndex() < 0 || TodayIndex() >= WindowDays() {
+		s += style.grayBlurredStyle.Render("  " + keyHint(m.keys.Today) + " back to today")
+	}
+	return s
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// openQuickAdd shows the one line prompt for adding an event.
+func (m *Model) openQuickAdd() tea.Cmd {
+	m.mode = quickAdd
+	m.quickInput = textinput.New()
+	m.quickInput.Placeholder = "standup tomorrow 9:30-9:45 @ room 4"
+	m.quickInput.Cursor.Style = style.cursorStyle
+	m.quickInput.PromptStyle = style.focusedStyle
+	m.quickInput.TextStyle = style.focusedStyle
+	m.quickEvent, m.quickErr = Event{}, nil
+	m.keys.Quit.SetEnabled(false)
+	return m.quickInput.Focus()
+}
+
+func (m Model) updateQuickAdd(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch msg.String() {
+		case "ctrl+c":
+			return m, tea.Quit
+		case "esc":
+			m.mode = calendar
+			m.keys.Quit.SetEnabled(true)
+			return m, nil
+		case "enter":
+			if m.quickErr != nil || m.quickEvent.Summary == "" {
+				return m, nil
+			}
+			m.mode = loading
+			m.keys.Quit.SetEnabled(true)
+			return m, saveEventCmd(m.backend, opCreate, Event{}, m.quickEvent)
+		case "tab":
+			// Finish the event in the full form
+			if m.quickErr != nil || m.quickEvent.Summary == "" {
+				return m, nil
+			}
+			m.openForm(m.quickEvent, DaysBetween(Now(), m.quickEvent.Start.DateTime))
+			return m, nil
+		}
+	}
+
+	var cmd tea.Cmd
+	m.quickInput, cmd = m.quickInput.Update(msg)
+	if m.quickInput.Value() == "" {
+		m.quickEvent, m.quickErr = Event{}, nil
+	} else {
+		m.quickEvent, m.quickErr = ParseQuickAdd(m.quickInput.Value(), Now())
+	}
+	return m, cmd
+}
+
+func (m Model) quickAddView() string {
+	s := "\nQuick add (enter to add, tab to open in the form, esc to cancel)\n\n"
+	s += m.quickInput.View() + "\n\n"
+	if m.quickErr != nil {
+		return s + style.warningStyle.Render(m.quickErr.Error()) + "\n"
+	}
+	if m.quickEvent.Summary == "" {
+		return s + style.grayBlurredStyle.Render("e.g. lunch fri 12 for 1h, review 2pm-3pm @ office, trip mon all day") + "\n"
+	}
+
+	event := m.quickEvent
+	s += fmt.Sprintf("%s\n%s\n", style.focusedStyle.Render(event.Summary), EventWhen(event))
+	if event.Location != "" {
+		s += "@ " + event.Location + "\n"
+	}
+	if name := m.calendarName(event); name != "" {
+		s += "Calendar: " + name + "\n"
+	}
+	if day := DaysBetween(WindowStart(), event.Start.DateTime.In(CalendarLocation())); day < 0 || day >= WindowDays() {
+		s += style.warningStyle.Render("This is outside the days shown") + "\n"
+	}
+	return s
+}
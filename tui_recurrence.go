@@ -0,0 +1,213 @@
+package main
+
+import (
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+var scopeLabels = []string{"This event", "This and following events", "All events"}
+
+type seriesLoadedMsg struct {
+	instanceID string
+	series     Event
+	err        error
+}
+
+// loadSeriesCmd fetches the recurring event an instance belongs to, so the
+// form can show its rule.
+func loadSeriesCmd(backend CalendarBackend, instance Event) tea.Cmd {
+	return func() tea.Msg {
+		series, err := backend.GetEvent(instance.CalendarID, instance.RecurringEventId)
+		return seriesLoadedMsg{instanceID: instance.Id, series: series, err: err}
+	}
+}
+
+// askScope switches to the prompt asking which occurrences op applies to.
+func (m *Model) askScope(op string, event Event) {
+	m.mode = recurrenceScope
+	m.scopeIdx = 0
+	m.scopeOp = op
+	m.scopeEvent = event
+}
+
+func (m Model) updateRecurrenceScope(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch s := msg.String(); {
+		case s == "ctrl+c":
+			return m, tea.Quit
+		case s == "esc":
+			m.mode = forms
+		case s == "up", s == "shift+tab", key.Matches(msg, m.keys.Up):
+			if m.scopeIdx > 0 {
+				m.scopeIdx--
+			}
+		case s == "down", s == "tab", key.Matches(msg, m.keys.Down):
+			if m.scopeIdx < len(scopeLabels)-1 {
+				m.scopeIdx++
+			}
+		case s == "enter":
+			if m.scopeOp == opDelete {
+				m.cursor.y -= 1
+			}
+			for i := range m.validFields {
+				m.validFields[i] = true
+			}
+			m.newEvent = false
+			m.mode = loading
+			return m, applyScopeCmd(m.backend, m.scopeOp, m.scopeIdx, m.formEvent, m.scopeEvent, m.formSeries)
+		}
+	}
+	return m, nil
+}
+
+func (m Model) recurrenceScopeView() string {
+	verb := "Edit"
+	if m.scopeOp == opDelete {
+		verb = "Delete"
+	}
+	s := "\n" + verb + " recurring event " + style.focusedStyle.Render(m.formEvent.Summary) + "\n\n"
+	for i, label := range scopeLabels {
+		if i == m.scopeIdx {
+			s += style.focusedStyle.Render("> "+label) + "\n"
+		} else {
+			s += "  " + label + "\n"
+		}
+	}
+	return s + "\n" + style.grayBlurredStyle.Render("enter to confirm, esc to go back") + "\n"
+}
+
+// InstanceStart is when an occurrence was scheduled by its rule, read from
+// the "<master id>_<start>" instance id. Moved occurrences keep that id, so
+// it is a better anchor than their current start.
+func InstanceStart(instance Event) time.Time {
+	i := strings.LastIndex(instance.Id, "_")
+	if i < 0 {
+		return instance.Start.DateTime
+	}
+	suffix := instance.Id[i+1:]
+	if t, err := time.Parse("20060102T150405Z", suffix); err == nil {
+		return t
+	}
+	if t, err := time.ParseInLocation("20060102", suffix, CalendarLocation()); err == nil {
+		return t
+	}
+	return instance.Start.DateTime
+}
+
+// applyScopeCmd carries out an edit or delete of the occurrence instance
+// for the occurrences picked in the prompt. edited is the form's content and
+// series the recurring event, fetched again when the form did not get it.
+func applyScopeCmd(backend CalendarBackend, op string, scope int, instance Event, edited Event, series Event) tea.Cmd {
+	return func() tea.Msg {
+		var err error
+		var changes []Change
+		result := edited
+		if scope != scopeThis && series.Id == "" {
+			series, err = backend.GetEvent(instance.CalendarID, instance.RecurringEventId)
+			if err != nil {
+				return eventSavedMsg{op: op, event: edited, err: err}
+			}
+		}
+		instance.SendUpdates = edited.SendUpdates
+		series.SendUpdates = edited.SendUpdates
+		label := ChangeLabel(op, edited) + " (" + strings.ToLower(scopeLabels[scope]) + ")"
+
+		switch scope {
+		case scopeThis:
+			if op == opDelete {
+				err = backend.DeleteEvent(instance)
+				changes = append(changes, Change{Op: opDelete, Before: instance})
+				break
+			}
+			edited.Recurrence = nil
+			result, err = backend.UpdateEvent(edited)
+			changes = append(changes, Change{Op: opUpdate, Before: instance, After: result})
+		case scopeAll:
+			before := series
+			if op == opDelete {
+				err = backend.DeleteEvent(series)
+				changes = append(changes, Change{Op: opDelete, Before: series})
+				break
+			}
+			// Shift the whole series by however far this occurrence moved
+			shift := edited.Start.DateTime.Sub(InstanceStart(instance))
+			series.Summary = edited.Summary
+			series.Location = edited.Location
+			series.AllDay = edited.AllDay
+			series.Attendees = edited.Attendees
+			series.GuestsCanModify = edited.GuestsCanModify
+			series.GuestsCanInviteOthers = edited.GuestsCanInviteOthers
+			series.GuestsCanSeeOtherGuests = edited.GuestsCanSeeOtherGuests
+			series.AddConference = edited.AddConference
+			series.Reminders = edited.Reminders
+			series.CustomReminders = edited.CustomReminders
+			series.Start.DateTime = series.Start.DateTime.Add(shift)
+			series.End.DateTime = series.Start.DateTime.Add(edited.End.DateTime.Sub(edited.Start.DateTime))
+			if len(edited.Recurrence) > 0 {
+				series.Recurrence = ReplaceRule(series.Recurrence, edited.Recurrence)
+			}
+			result, err = backend.UpdateEvent(normalizeEvent(series))
+			changes = append(changes, Change{Op: opUpdate, Before: before, After: result})
+		case scopeFollowing:
+			original := series.Recurrence
+			before := series
+			from := InstanceStart(instance)
+			if !from.After(series.Start.DateTime) {
+				// Splitting at the first occurrence leaves nothing before it
+				err = backend.DeleteEvent(series)
+				changes = append(changes, Change{Op: opDelete, Before: series})
+			} else {
+				truncated, terr := TruncateRecurrence(series.Recurrence, from, series.AllDay)
+				if terr != nil {
+					return eventSavedMsg{op: op, event: edited, err: terr}
+				}
+				series.Recurrence = truncated
+				var truncatedSeries Event
+				truncatedSeries, err = backend.UpdateEvent(series)
+				changes = append(changes, Change{Op: opUpdate, Before: before, After: truncatedSeries})
+			}
+			if err != nil || op == opDelete {
+				break
+			}
+			following := edited
+			following.Id = ""
+			following.RecurringEventId = ""
+			if len(following.Recurrence) == 0 {
+				following.Recurrence = continueRecurrence(original, series.AllDay)
+			}
+			result, err = backend.CreateEvent(following)
+			changes = append(changes, Change{Op: opCreate, After: result})
+		}
+		if err != nil {
+			result = edited
+			if len(changes) > 0 {
+				// The failed write is last, anything before it went through
+				changes = changes[:len(changes)-1]
+			}
+		}
+		return eventSavedMsg{op: op, event: result, changes: changes, label: label, err: err}
+	}
+}
+
+// continueRecurrence is the rule for the new series that "this and
+// following" starts. A COUNT cannot be carried over, so it is dropped.
+func continueRecurrence(recurrence []string, allDay bool) []string {
+	var rules []string
+	for _, line := range recurrence {
+		if !strings.HasPrefix(line, "RRULE:") {
+			rules = append(rules, line)
+			continue
+		}
+		rule, err := ParseRRule(line)
+		if err != nil {
+			continue
+		}
+		rule.Count = 0
+		rules = append(rules, rule.String(allDay))
+	}
+	return rules
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// hideDeclined drops events we declined from the week instead of dimming
+// them, set with HIDE_DECLINED.
+var hideDeclined bool
+
+var responses = []struct {
+	status string
+	label  string
+}{
+	{"accepted", "Yes"},
+	{"tentative", "Maybe"},
+	{"declined", "No"},
+}
+
+// SelfResponse is our answer to an invitation, empty when we are not a
+// guest of the event.
+func SelfResponse(event Event) string {
+	for _, attendee := range event.Attendees {
+		if attendee.Self {
+			if attendee.ResponseStatus == "" {
+				return "needsAction"
+			}
+			return attendee.ResponseStatus
+		}
+	}
+	return ""
+}
+
+// WithResponse is event with our guest entry answering status.
+func WithResponse(event Event, status string, comment string) Event {
+	attendees := append([]Attendee(nil), event.Attendees...)
+	for i := range attendees {
+		if attendees[i].Self {
+			attendees[i].ResponseStatus = status
+			attendees[i].Comment = comment
+		}
+	}
+	event.Attendees = attendees
+	return event
+}
+
+// VisibleEvents drops declined events when HIDE_DECLINED is set.
+func VisibleEvents(events []Event) []Event {
+	if !hideDeclined {
+		return events
+	}
+	var visible []Event
+	for _, event := range events {
+		if SelfResponse(event) != "declined" {
+			visible = append(visible, event)
+		}
+	}
+	return visible
+}
+
+// rsvpCardStyle marks cards we have not answered, tentatively accepted or
+// declined.
+func rsvpCardStyle(card lipgloss.Style, event Event) lipgloss.Style {
+	if SelfResponse(event) == "needsAction" {
+		return card.BorderStyle(style.invitedBorder)
+	}
+	return rsvpTextStyle(card, event)
+}
+
+// rsvpTextStyle is rsvpCardStyle for text without a border, where the ✉ of
+// CardSummary is the only mark of an unanswered invitation.
+func rsvpTextStyle(text lipgloss.Style, event Event) lipgloss.Style {
+	switch SelfResponse(event) {
+	case "tentative":
+		return text.Italic(true)
+	case "declined":
+		return text.Faint(true).Strikethrough(true)
+	}
+	return text
+}
+
+// openRsvp asks for our response to an invitation. Events we are not a
+// guest of have nothing to answer.
+func (m *Model) openRsvp(event Event) tea.Cmd {
+	if SelfResponse(event) == "" {
+		m.status = "You are not a guest of this event"
+		return nil
+	}
+	m.rsvpReturn = m.mode
+	m.mode = rsvp
+	m.rsvpEvent = event
+	m.rsvpIdx = 0
+	for i, response := range responses {
+		if response.status == SelfResponse(event) {
+			m.rsvpIdx = i
+		}
+	}
+	m.rsvpComment = textinput.New()
+	m.rsvpComment.Placeholder = "optional comment"
+	m.rsvpComment.Cursor.Style = style.cursorStyle
+	for _, attendee := range event.Attendees {
+		if attendee.Self {
+			m.rsvpComment.SetValue(attendee.Comment)
+		}
+	}
+	return m.rsvpComment.Focus()
+}
+
+func (m Model) updateRsvp(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch msg.String() {
+		case "ctrl+c":
+			return m, tea.Quit
+		case "esc":
+			m.mode = m.rsvpReturn
+			return m, nil
+		case "left", "up", "shift+tab":
+			if m.rsvpIdx > 0 {
+				m.rsvpIdx--
+			}
+			return m, nil
+		case "right", "down", "tab":
+			if m.rsvpIdx < len(responses)-1 {
+				m.rsvpIdx++
+			}
+			return m, nil
+		case "enter":
+			event := WithResponse(m.rsvpEvent, responses[m.rsvpIdx].status, m.rsvpComment.Value())
+			m.mode = loading
+			return m, saveEventCmd(m.backend, opRespond, event, event)
+		}
+	}
+	var cmd tea.Cmd
+	m.rsvpComment, cmd = m.rsvpComment.Update(msg)
+	return m, cmd
+}
+
+func (m Model) rsvpView() string {
+	s := fmt.Sprintf("\nGoing to %s?\n%s\n\n", style.focusedStyle.Render(m.rsvpEvent.Summary), EventWhen(m.rsvpEvent))
+	for i, response := range responses {
+		if i == m.rsvpIdx {
+			s += style.focusedStyle.Render("[ "+response.label+" ]") + " "
+		} else {
+			s += "  " + response.label + "   "
+		}
+	}
+	s += "\n\n" + m.rsvpComment.View() + "\n\n"
+	return s + style.grayBlurredStyle.Render("←/→ choose, enter to send, esc to go back") + "\n"
+}
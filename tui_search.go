@@ -0,0 +1,241 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// searchResultRows is how many calendar search results are listed at once.
+const searchResultRows = 8
+
+type searchResultsMsg struct {
+	text   string
+	events []Event
+	err    error
+}
+
+func searchEventsCmd(backend CalendarBackend, text string) tea.Cmd {
+	timeMin, timeMax := SearchBounds()
+	return func() tea.Msg {
+		events, err := backend.SearchEvents(text, timeMin, timeMax)
+		return searchResultsMsg{text: text, events: events, err: err}
+	}
+}
+
+// setEvents replaces the events of the days on screen. All of them are kept
+// in loaded, for conflicts and free time, and the ones matching the search
+// filter in events, which the views show.
+// The conflicts and layouts the views draw are worked out here once rather
+// than on every render.
+func (m *Model) setEvents(events []Event) {
+	m.loaded = events
+	m.events = FilterEvents(events, m.filter)
+	m.eventMatrix = CreateEventMatrix(m.events)
+	m.conflicting = ConflictingIds(m.loaded)
+	m.allDayLanes = CreateAllDayLanes(m.events)
+	m.dayLayouts = make([][]TimeGridBlock, WindowDays())
+	for day := range m.dayLayouts {
+		m.dayLayouts[day] = LayoutDay(m.events, day)
+	}
+}
+
+// applyFilter shows only the events matching filter, keeping the cursor on
+// the same event or day when it is still shown.
+func (m *Model) applyFilter(filter string) {
+	event, day := m.selection()
+	m.filter = filter
+	m.setEvents(m.loaded)
+	m.clampCursor()
+	m.placeView(event, day)
+}
+
+// matches are the events n and N step through. The grid and the time grid
+// show all-day events in the banner, where the cursor cannot go.
+func (m Model) matches() []Event {
+	if m.view == viewAgenda {
+		return m.events
+	}
+	var matches []Event
+	for _, event := range m.events {
+		if !event.AllDay {
+			matches = append(matches, event)
+		}
+	}
+	return matches
+}
+
+// nextMatch moves the cursor to the next, or previous, event matching the
+// filter, wrapping around the days on screen.
+func (m *Model) nextMatch(forward bool) {
+	matches := m.matches()
+	if len(matches) == 0 {
+		return
+	}
+	current, day := m.selection()
+	next := -1
+	for i, event := range matches {
+		if current.Id != "" && event.Id == current.Id {
+			next = (i + 1) % len(matches)
+			if !forward {
+				next = (i - 1 + len(matches)) % len(matches)
+			}
+			break
+		}
+	}
+	if next < 0 {
+		// Not on a match, take the first one from the selected day on, or
+		// the last one up to it
+		next = 0
+		if !forward {
+			next = len(matches) - 1
+		}
+		for i, event := range matches {
+			eventDay := DateToIndex(EventDate(event))
+			if forward && eventDay >= day {
+				next = i
+				break
+			}
+			if !forward && eventDay <= day {
+				next = i
+			}
+		}
+	}
+	event := matches[next]
+	m.placeView(event, DateToIndex(EventDate(event)))
+}
+
+// openSearch shows the search prompt, which filters the views as it is
+// typed.
+func (m *Model) openSearch() tea.Cmd {
+	m.mode = search
+	m.searchInput = textinput.New()
+	m.searchInput.Prompt = "/"
+	m.searchInput.Placeholder = "summary, place, description or guest"
+	m.searchInput.Cursor.Style = style.cursorStyle
+	m.searchInput.PromptStyle = style.focusedStyle
+	m.searchInput.TextStyle = style.focusedStyle
+	m.searchInput.SetValue(m.filter)
+	m.searchInput.CursorEnd()
+	m.searchText, m.searchResult, m.searchIdx, m.searchErr, m.searching = "", nil, 0, nil, false
+	return m.searchInput.Focus()
+}
+
+// jumpToResult moves the window to the week of the picked search result.
+func (m *Model) jumpToResult() tea.Cmd {
+	event := m.searchResult[m.searchIdx]
+	m.mode = calendar
+	if !MatchesSearch(event, m.filter) {
+		// Google matched it on something we do not search, like an
+		// attachment, so the filter would hide it
+		m.filter = ""
+	}
+	cmd := m.showDay(event.Start.DateTime.In(CalendarLocation()))
+	m.jumpTo = event
+	m.placeView(event, DateToIndex(EventDate(event)))
+	return cmd
+}
+
+func (m Model) updateSearch(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case searchResultsMsg:
+		if msg.text != m.searchText {
+			return m, nil
+		}
+		m.searching = false
+		if msg.err != nil && !errors.Is(msg.err, ErrOffline) {
+			m.searchErr = msg.err
+			return m, nil
+		}
+		// Start on the first result from today on
+		m.searchResult, m.searchIdx = msg.events, 0
+		today := StartOfDay(Now())
+		for i, event := range msg.events {
+			if !event.End.DateTime.Before(today) {
+				m.searchIdx = i
+				break
+			}
+		}
+		return m, nil
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "ctrl+c":
+			return m, tea.Quit
+		case "esc":
+			m.mode = calendar
+			m.applyFilter("")
+			return m, nil
+		case "up", "ctrl+p":
+			if m.searchIdx > 0 {
+				m.searchIdx--
+			}
+			return m, nil
+		case "down", "ctrl+n":
+			if m.searchIdx < len(m.searchResult)-1 {
+				m.searchIdx++
+			}
+			return m, nil
+		case "tab":
+			// Search the whole calendar, not just the days on screen
+			text := strings.TrimSpace(m.searchInput.Value())
+			if text == "" || m.searching {
+				return m, nil
+			}
+			m.searchText, m.searchResult, m.searchErr, m.searching = text, nil, nil, true
+			return m, searchEventsCmd(m.backend, text)
+		case "enter":
+			if len(m.searchResult) > 0 && strings.TrimSpace(m.searchInput.Value()) == m.searchText {
+				return m, m.jumpToResult()
+			}
+			m.mode = calendar
+			if event, _ := m.selection(); m.filter != "" && event.Id == "" {
+				m.nextMatch(true)
+			}
+			return m, nil
+		}
+		var cmd tea.Cmd
+		m.searchInput, cmd = m.searchInput.Update(msg)
+		if m.searchInput.Value() != m.filter {
+			m.applyFilter(m.searchInput.Value())
+			m.searchText, m.searchResult, m.searchErr, m.searching = "", nil, nil, false
+		}
+		return m, cmd
+	}
+	var cmds [2]tea.Cmd
+	m.searchInput, cmds[0] = m.searchInput.Update(msg)
+	m.spinner, cmds[1] = m.spinner.Update(msg)
+	return m, tea.Batch(cmds[:]...)
+}
+
+// searchView is the prompt under the views while searching, with the
+// results of a calendar search when there are some.
+func (m Model) searchView() string {
+	s := m.searchInput.View() + "\n"
+	switch {
+	case m.searchErr != nil:
+		s += style.errorStyle.Render(m.searchErr.Error()) + "\n"
+	case m.searching:
+		s += "Searching the calendar " + m.spinner.View() + "\n"
+	case m.searchText != "" && len(m.searchResult) == 0:
+		s += style.warningStyle.Render("Nothing in the calendar matches") + "\n"
+	case len(m.searchResult) > 0:
+		first := max(min(m.searchIdx-searchResultRows/2, len(m.searchResult)-searchResultRows), 0)
+		last := min(first+searchResultRows, len(m.searchResult))
+		for i := first; i < last; i++ {
+			event := m.searchResult[i]
+			line := fmt.Sprintf("%s  %s", EventWhen(event), event.Summary)
+			if i == m.searchIdx {
+				s += style.focusedStyle.Render("› "+line) + "\n"
+			} else {
+				s += "  " + line + "\n"
+			}
+		}
+		s += style.grayBlurredStyle.Render(fmt.Sprintf("%d found, ↑/↓ and enter to go to one", len(m.searchResult))) + "\n"
+	default:
+		s += style.grayBlurredStyle.Render("enter to keep the filter, tab to search the whole calendar, esc to clear") + "\n"
+	}
+	return s
+}
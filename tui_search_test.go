@@ -0,0 +1,50 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestSearchCalendar(t *testing.T) {
+	start := StartOfDay(Now()).AddDate(0, 0, 1).Add(9 * time.Hour)
+	backend := NewMemoryBackend(nil, []Event{{
+		Summary: "Dentist",
+		Start:   DateTime{DateTime: start},
+		End:     DateTime{DateTime: start.Add(time.Hour)},
+	}})
+	tests := []struct {
+		query   string
+		results int
+	}{
+		{"dentist", 1},
+		{"  dentist  ", 1},
+		{"lunch", 0},
+	}
+	for _, tt := range tests {
+		m := Model{backend: backend}
+		m.openSearch()
+		m.searchInput.SetValue(tt.query)
+		next, cmd := m.updateSearch(tea.KeyMsg{Type: tea.KeyTab})
+		m = next.(Model)
+		if cmd == nil {
+			t.Fatalf("%q: tab did not search", tt.query)
+		}
+		next, _ = m.updateSearch(cmd())
+		m = next.(Model)
+		if m.searching {
+			t.Errorf("%q: still searching after the results came", tt.query)
+		}
+		if len(m.searchResult) != tt.results {
+			t.Errorf("%q: %d results, want %d", tt.query, len(m.searchResult), tt.results)
+		}
+		if len(m.searchResult) == 0 {
+			continue
+		}
+		next, _ = m.updateSearch(tea.KeyMsg{Type: tea.KeyEnter})
+		if m = next.(Model); m.jumpTo.Id != m.searchResult[0].Id {
+			t.Errorf("%q: enter did not jump to the result", tt.query)
+		}
+	}
+}
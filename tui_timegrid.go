@@ -0,0 +1,499 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const (
+	// slotMinutes is how long one row of the time grid is.
+	slotMinutes = 30
+	slotsPerDay = 24 * 60 / slotMinutes
+	// timeLabelWidth is the column of hour labels left of the days.
+	timeLabelWidth = 6
+)
+
+// workStart and workEnd are the hours the time grid always shows, in
+// minutes after midnight, set with WORKING_HOURS.
+var (
+	workStart = 8 * 60
+	workEnd   = 18 * 60
+)
+
+// ParseWorkingHours reads a range like "8-18" or "8:30-17:30".
+func ParseWorkingHours(value string) (int, int, error) {
+	parts := strings.Split(strings.ReplaceAll(value, " ", ""), "-")
+	if len(parts) == 2 {
+		start, okStart := parseClock(parts[0])
+		end, okEnd := parseClock(parts[1])
+		if okStart && okEnd && start.minutes("") < end.minutes("") {
+			return start.minutes(""), end.minutes(""), nil
+		}
+	}
+	return 0, 0, fmt.Errorf("%q is not a range of hours like 8-18", value)
+}
+
+// TimeGridBlock is an event placed on one day of the time grid. From and
+// To are the slots it covers, To excluded. Events that overlap share the
+// width of the day, Column of Columns each.
+type TimeGridBlock struct {
+	Event   Event
+	From    int
+	To      int
+	Column  int
+	Columns int
+}
+
+// LayoutDay places the timed events of day, counted from the first day on
+// screen, into slots
+// and side by side columns. Events running past midnight are cut at the
+// edges of the day.
+func LayoutDay(events []Event, day int) []TimeGridBlock {
+	dayStart := WindowStart().AddDate(0, 0, day)
+	dayEnd := dayStart.AddDate(0, 0, 1)
+	var blocks []TimeGridBlock
+	for _, event := range events {
+		if event.AllDay || !event.Start.DateTime.Before(dayEnd) || !event.End.DateTime.After(dayStart) {
+			continue
+		}
+		from := 0
+		if event.Start.DateTime.After(dayStart) {
+			from = slotOf(event.Start.DateTime)
+		}
+		to := slotsPerDay
+		if event.End.DateTime.Before(dayEnd) {
+			end := event.End.DateTime.In(CalendarLocation())
+			to = (end.Hour()*60 + end.Minute() + slotMinutes - 1) / slotMinutes
+		}
+		blocks = append(blocks, TimeGridBlock{Event: event, From: from, To: max(to, from+1)})
+	}
+	sort.SliceStable(blocks, func(i, j int) bool {
+		if blocks[i].From != blocks[j].From {
+			return blocks[i].From < blocks[j].From
+		}
+		return blocks[i].To > blocks[j].To
+	})
+
+	// Events that overlap, directly or through another event, form a group
+	// that is split into as many columns as it needs
+	var columnEnds []int
+	groupStart, groupEnd := 0, 0
+	closeGroup := func(end int) {
+		for i := groupStart; i < end; i++ {
+			blocks[i].Columns = len(columnEnds)
+		}
+	}
+	for i := range blocks {
+		if i > 0 && blocks[i].From >= groupEnd {
+			closeGroup(i)
+			groupStart = i
+			columnEnds = nil
+		}
+		column := len(columnEnds)
+		for c, end := range columnEnds {
+			if end <= blocks[i].From {
+				column = c
+				break
+			}
+		}
+		if column == len(columnEnds) {
+			columnEnds = append(columnEnds, 0)
+		}
+		columnEnds[column] = blocks[i].To
+		blocks[i].Column = column
+		groupEnd = max(groupEnd, blocks[i].To)
+	}
+	closeGroup(len(blocks))
+	return blocks
+}
+
+// slotOf is the slot of the day t falls in.
+func slotOf(t time.Time) int {
+	t = t.In(CalendarLocation())
+	return (t.Hour()*60 + t.Minute()) / slotMinutes
+}
+
+// blocksAt is the blocks covering slot, left to right.
+func blocksAt(blocks []TimeGridBlock, slot int) []TimeGridBlock {
+	var covering []TimeGridBlock
+	for _, block := range blocks {
+		if block.From <= slot && slot < block.To {
+			covering = append(covering, block)
+		}
+	}
+	sort.Slice(covering, func(i, j int) bool { return covering[i].Column < covering[j].Column })
+	return covering
+}
+
+// timeGridDays is the first day and the number of days on screen.
+func (m Model) timeGridDays() (int, int) {
+	if m.dayView {
+		return m.slotDay, 1
+	}
+	return 0, WindowDays()
+}
+
+// timeGridRange is the slots shown: the working hours, stretched to the
+// whole hours of any event on screen and of the cursor.
+func (m Model) timeGridRange() (int, int) {
+	first, last := workStart/slotMinutes, (workEnd+slotMinutes-1)/slotMinutes
+	firstDay, days := m.timeGridDays()
+	for day := firstDay; day < firstDay+days; day++ {
+		for _, block := range m.dayLayout(day) {
+			first = min(first, block.From)
+			last = max(last, block.To)
+		}
+	}
+	first = min(first, m.slot)
+	last = max(last, m.slot+1)
+	perHour := 60 / slotMinutes
+	return first / perHour * perHour, min((last+perHour-1)/perHour*perHour, slotsPerDay)
+}
+
+// dayLayout is the time grid layout of day as setEvents worked it out.
+func (m Model) dayLayout(day int) []TimeGridBlock {
+	if day < 0 || day >= len(m.dayLayouts) {
+		return nil
+	}
+	return m.dayLayouts[day]
+}
+
+// eventAtSlot is the event under the time grid cursor. Where events overlap
+// slotPick chooses between them.
+func (m Model) eventAtSlot() (Event, bool) {
+	covering := blocksAt(m.dayLayout(m.slotDay), m.slot)
+	if len(covering) == 0 {
+		return Event{}, false
+	}
+	return covering[m.slotPick%len(covering)].Event, true
+}
+
+// timeGridHeight is how many slots fit on screen, zero when the terminal
+// size is not known yet.
+func (m Model) timeGridHeight() int {
+	if m.height <= 0 {
+		return 0
+	}
+	// The header's height as lipgloss counts it: the day names and the
+	// lanes shown, each ending in a newline
+	firstDay, days := m.timeGridDays()
+	header := 2
+	for _, lane := range m.allDayLanes {
+		if laneShown(lane, firstDay, days) {
+			header++
+		}
+	}
+	used := header + lipgloss.Height(m.help.View(m.keys)) + lipgloss.Height(m.statusLine()) + 2
+	return max(m.height-used, 4)
+}
+
+// scrollTimeGrid keeps the cursor's slot on screen.
+func (m *Model) scrollTimeGrid() {
+	m.slotDay = max(min(m.slotDay, WindowDays()-1), 0)
+	m.slot = max(min(m.slot, slotsPerDay-1), 0)
+	height := m.timeGridHeight()
+	first, last := m.timeGridRange()
+	if height == 0 || last-first <= height {
+		m.slotTop = first
+		return
+	}
+	if m.slot < m.slotTop {
+		m.slotTop = m.slot
+	}
+	if m.slot >= m.slotTop+height {
+		m.slotTop = m.slot - height + 1
+	}
+	m.slotTop = max(min(m.slotTop, last-height), first)
+}
+
+// placeTimeGrid puts the cursor on event, or on the current time of day
+// when there is no event to put it on.
+func (m *Model) placeTimeGrid(event Event, day int) {
+	m.slotDay = day
+	m.slotPick = 0
+	switch {
+	case event.Id != "" && !event.AllDay:
+		m.slotDay = max(DateToIndex(EventDate(event)), 0)
+		m.slot = slotOf(event.Start.DateTime)
+		for i, block := range blocksAt(m.dayLayout(m.slotDay), m.slot) {
+			if block.Event.Id == event.Id {
+				m.slotPick = i
+			}
+		}
+	case day == TodayIndex():
+		m.slot = slotOf(Now())
+	default:
+		m.slot = workStart / slotMinutes
+	}
+	m.scrollTimeGrid()
+}
+
+// openFormAtSlot opens the form for a new event starting at the cursor.
+func (m *Model) openFormAtSlot() {
+	m.openForm(Event{Summary: "+"}, m.slotDay)
+	start := atMinutes(WindowStart().AddDate(0, 0, m.slotDay), m.slot*slotMinutes)
+	end := start.Add(defaultDuration)
+	m.inputs[Date].SetValue(start.Format(time.DateOnly))
+	m.inputs[StartTime].SetValue(start.Format("15:04"))
+	m.inputs[EndDate].SetValue(end.Format(time.DateOnly))
+	m.inputs[EndTime].SetValue(end.Format("15:04"))
+}
+
+func (m Model) updateTimeGrid(msg tea.Msg) (tea.Model, tea.Cmd) {
+	m.keys.Flip.SetEnabled(false)
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.help.Width = msg.Width
+		m.scrollTimeGrid()
+	case tea.KeyMsg:
+		event, onEvent := m.eventAtSlot()
+		switch {
+		case msg.String() == "ctrl+c", key.Matches(msg, m.keys.Quit):
+			return m, tea.Quit
+		case key.Matches(msg, m.keys.Help):
+			m.help.ShowAll = !m.help.ShowAll
+		case key.Matches(msg, m.keys.Up):
+			m.slot--
+			m.slotPick = 0
+		case key.Matches(msg, m.keys.Down):
+			m.slot++
+			m.slotPick = 0
+		case key.Matches(msg, m.keys.Left):
+			m.slotDay--
+			m.slotPick = 0
+		case key.Matches(msg, m.keys.Right):
+			m.slotDay++
+			m.slotPick = 0
+		case key.Matches(msg, m.keys.Cycle):
+			// Step through events that overlap at the cursor
+			m.slotPick++
+		case key.Matches(msg, m.keys.DayWeek):
+			m.dayView = !m.dayView
+		case key.Matches(msg, m.keys.View):
+			m.switchView()
+			return m, nil
+		case key.Matches(msg, m.keys.QuickAdd):
+			return m, m.openQuickAdd()
+		case key.Matches(msg, m.keys.FindTime):
+			return m, m.openFindTime()
+		case key.Matches(msg, m.keys.NewAt):
+			m.openFormAtSlot()
+			return m, nil
+		case key.Matches(msg, m.keys.Details):
+			if onEvent {
+				m.openDetail(event)
+			}
+			return m, nil
+		case key.Matches(msg, m.keys.Rsvp):
+			if !onEvent {
+				return m, nil
+			}
+			return m, m.openRsvp(event)
+		case key.Matches(msg, m.keys.Calendars):
+			m.mode = calendarPicker
+			m.calendarIdx = 0
+			return m, nil
+		case key.Matches(msg, m.keys.Open):
+			if !onEvent {
+				m.openFormAtSlot()
+				return m, nil
+			}
+			m.openForm(event, m.slotDay)
+			if m.formEvent.RecurringEventId != "" {
+				return m, loadSeriesCmd(m.backend, m.formEvent)
+			}
+			return m, nil
+		}
+		m.scrollTimeGrid()
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.spinner, cmd = m.spinner.Update(msg)
+	return m, cmd
+}
+
+// timeGridColumnWidth is the width of one day on screen.
+func (m Model) timeGridColumnWidth() int {
+	width := m.help.Width
+	if width <= 0 {
+		width = 80
+	}
+	_, days := m.timeGridDays()
+	return max((width-timeLabelWidth)/days, 6)
+}
+
+// timeGridHeader is the day names and the all-day events above the hours.
+func (m Model) timeGridHeader() string {
+	firstDay, days := m.timeGridDays()
+	columnWidth := m.timeGridColumnWidth()
+	start := WindowStart()
+
+	header := strings.Repeat(" ", timeLabelWidth)
+	for day := firstDay; day < firstDay+days; day++ {
+		label := start.AddDate(0, 0, day).Format("Mon 2")
+		if m.dayView {
+			label = start.AddDate(0, 0, day).Format("Monday 2 January")
+		}
+		dayStyle := style.dayStyle.Width(columnWidth)
+		if day == m.slotDay {
+			dayStyle = dayStyle.Foreground(style.focusedStyle.GetForeground()).Bold(true)
+		}
+		header += dayStyle.Render(label)
+	}
+	s := header + "\n"
+
+	for _, lane := range m.allDayLanes {
+		if !laneShown(lane, firstDay, days) {
+			continue
+		}
+		row := strings.Repeat(" ", timeLabelWidth)
+		column := firstDay
+		for _, span := range lane {
+			start, end := max(span.Start, firstDay), min(span.End, firstDay+days-1)
+			if start > end {
+				continue
+			}
+			row += strings.Repeat(" ", (start-column)*columnWidth)
+			width := (end-start+1)*columnWidth - 1
+			bar := style.allDayEventStyle
+			if color := m.calendarColor(span.Event); color != "" {
+				bar = bar.Background(lipgloss.Color(color))
+			}
+			row += bar.Width(width).MaxWidth(width).Render(truncateWidth(m.markedSummary(CardSummary(span.Event), span.Event), width)) + " "
+			column = end + 1
+		}
+		s += row + "\n"
+	}
+	return s
+}
+
+// laneShown reports whether any bar of an all-day lane falls on the days
+// the time grid shows.
+func laneShown(lane []BannerSpan, firstDay int, days int) bool {
+	for _, span := range lane {
+		if span.Start <= firstDay+days-1 && span.End >= firstDay {
+			return true
+		}
+	}
+	return false
+}
+
+// truncateWidth cuts s to at most width cells.
+func truncateWidth(s string, width int) string {
+	if width <= 0 {
+		return ""
+	}
+	runes := []rune(s)
+	for lipgloss.Width(string(runes)) > width {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes)
+}
+
+// timeGridCell draws one slot of one day, split between the events that
+// overlap there.
+func (m Model) timeGridCell(blocks []TimeGridBlock, day int, slot int, width int, nowSlot int) string {
+	cursor := day == m.slotDay && slot == m.slot
+	empty := func(width int) string {
+		switch {
+		case cursor:
+			return style.focusedStyle.Reverse(true).Render(strings.Repeat(" ", width))
+		case day == TodayIndex() && slot == nowSlot:
+			return style.errorStyle.Render(strings.Repeat("─", width))
+		case slot%(60/slotMinutes) == 0:
+			return style.grayBlurredStyle.Faint(true).Render(strings.Repeat("┈", width))
+		}
+		return strings.Repeat(" ", width)
+	}
+
+	covering := blocksAt(blocks, slot)
+	if len(covering) == 0 {
+		return empty(width)
+	}
+	columns := covering[0].Columns
+	picked := -1
+	if cursor {
+		picked = covering[m.slotPick%len(covering)].Column
+	}
+	var cell strings.Builder
+	for column := range columns {
+		columnWidth := width / columns
+		if column == columns-1 {
+			columnWidth = width - width/columns*(columns-1)
+		}
+		var block *TimeGridBlock
+		for i := range covering {
+			if covering[i].Column == column {
+				block = &covering[i]
+			}
+		}
+		if block == nil {
+			cell.WriteString(empty(columnWidth))
+			continue
+		}
+
+		text := ""
+		switch slot {
+		case block.From:
+			text = m.markedSummary(CardSummary(block.Event), block.Event)
+		case block.From + 1:
+			loc := CalendarLocation()
+			text = block.Event.Start.DateTime.In(loc).Format("15:04") + "-" + block.Event.End.DateTime.In(loc).Format("15:04")
+		}
+		blockStyle := style.allDayEventStyle.Align(lipgloss.Left)
+		if color := m.calendarColor(block.Event); color != "" {
+			blockStyle = blockStyle.Background(lipgloss.Color(color))
+		}
+		blockStyle = rsvpTextStyle(blockStyle, block.Event)
+		if column == picked {
+			blockStyle = blockStyle.Reverse(true).Bold(true)
+		}
+		inner := max(columnWidth-1, 1)
+		cell.WriteString(blockStyle.Width(inner).Render(truncateWidth(text, inner)))
+		if columnWidth > 1 {
+			cell.WriteString(" ")
+		}
+	}
+	return cell.String()
+}
+
+func (m Model) timeGridView() string {
+	firstDay, days := m.timeGridDays()
+	columnWidth := m.timeGridColumnWidth()
+	first, last := m.timeGridRange()
+	top, bottom := first, last
+	if height := m.timeGridHeight(); height > 0 && last-first > height {
+		top = max(min(m.slotTop, last-height), first)
+		bottom = top + height
+	}
+
+	now := Now()
+	nowSlot := slotOf(now)
+	layouts := make([][]TimeGridBlock, days)
+	for i := range layouts {
+		layouts[i] = m.dayLayout(firstDay + i)
+	}
+
+	s := m.timeGridHeader()
+	for slot := top; slot < bottom; slot++ {
+		label := strings.Repeat(" ", timeLabelWidth)
+		switch {
+		case firstDay <= TodayIndex() && TodayIndex() < firstDay+days && slot == nowSlot:
+			label = style.errorStyle.Render(fmt.Sprintf("%-*s", timeLabelWidth, now.Format("15:04")))
+		case slot%(60/slotMinutes) == 0:
+			label = style.grayBlurredStyle.Render(fmt.Sprintf("%-*s", timeLabelWidth, fmt.Sprintf("%02d:00", slot*slotMinutes/60)))
+		}
+		s += label
+		for i := range days {
+			s += m.timeGridCell(layouts[i], firstDay+i, slot, columnWidth, nowSlot)
+		}
+		s += "\n"
+	}
+	return s
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"fmt"
+	"log"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// undoLimit is how many changes u can take back.
+const undoLimit = 50
+
+// Change is one write as it reached the calendar. Before is empty for a
+// create and After for a delete.
+type Change struct {
+	Op     string
+	Before Event
+	After  Event
+}
+
+// UndoEntry is what one u or ctrl+r takes back or does again: the changes
+// a single save made, in the order they were made.
+type UndoEntry struct {
+	Label   string
+	Changes []Change
+}
+
+// Inverse is the change that takes c back. Deleted events come back with
+// their whole payload, edits go back to the fields before them.
+func (c Change) Inverse() Change {
+	inverse := Change{Before: c.After, After: c.Before}
+	switch c.Op {
+	case opCreate:
+		inverse.Op = opDelete
+	case opDelete:
+		inverse.Op = opCreate
+	case opMove:
+		inverse.Op = opMove
+	default:
+		inverse.Op = opUpdate
+	}
+	// Tell guests the same way the change itself did
+	sendUpdates := c.After.SendUpdates
+	if c.Op == opDelete {
+		sendUpdates = c.Before.SendUpdates
+	}
+	inverse.Before.SendUpdates = sendUpdates
+	inverse.After.SendUpdates = sendUpdates
+	return inverse
+}
+
+// Inverse is the entry that takes every change of e back, last one first.
+func (e UndoEntry) Inverse() UndoEntry {
+	inverse := UndoEntry{Label: e.Label}
+	for i := len(e.Changes) - 1; i >= 0; i-- {
+		inverse.Changes = append(inverse.Changes, e.Changes[i].Inverse())
+	}
+	return inverse
+}
+
+// applyChange writes c and returns it as it happened, with the ids and
+// fields the calendar gave back.
+func applyChange(backend CalendarBackend, c Change) (Change, error) {
+	switch c.Op {
+	case opCreate:
+		event := c.After
+		event.Id = ""
+		event.Pending = false
+		event.AddConference = ConferenceLink(event) != ""
+		if event.RecurringEventId != "" {
+			// A deleted occurrence cannot be put back into its series, it
+			// comes back as a one-off copy
+			event.RecurringEventId = ""
+			event.Recurrence = nil
+		}
+		created, err := backend.CreateEvent(event)
+		c.After = created
+		return c, err
+	case opDelete:
+		return c, backend.DeleteEvent(c.Before)
+	case opMove:
+		moved, err := backend.MoveEvent(c.Before, c.After.CalendarID)
+		if err == nil {
+			c.After = moved
+		}
+		return c, err
+	}
+	event := c.After
+	event.Id = c.Before.Id
+	event.Pending = false
+	updated, err := backend.UpdateEvent(event)
+	c.After = updated
+	return c, err
+}
+
+type undoneMsg struct {
+	redo    bool
+	entry   UndoEntry
+	applied UndoEntry
+	err     error
+}
+
+// undoCmd applies entry, the inverse of what is being undone or redone, and
+// stops at the first change that fails.
+func undoCmd(backend CalendarBackend, entry UndoEntry, redo bool) tea.Cmd {
+	return func() tea.Msg {
+		applied := UndoEntry{Label: entry.Label}
+		for _, change := range entry.Changes {
+			done, err := applyChange(backend, change)
+			if err != nil {
+				return undoneMsg{redo: redo, entry: entry, applied: applied, err: err}
+			}
+			applied.Changes = append(applied.Changes, done)
+		}
+		return undoneMsg{redo: redo, entry: entry, applied: applied}
+	}
+}
+
+// ChangeLabel describes a save for the undo status line, like
+// `deleted "Lunch"`.
+func ChangeLabel(op string, event Event) string {
+	verb := "edited"
+	switch op {
+	case opCreate:
+		verb = "created"
+	case opDelete:
+		verb = "deleted"
+	case opMove:
+		verb = "moved"
+	}
+	return fmt.Sprintf("%s %q", verb, event.Summary)
+}
+
+// pushUndo records a save that can be taken back, which forgets anything
+// that was undone before it.
+func (m *Model) pushUndo(entry UndoEntry) {
+	if len(entry.Changes) == 0 {
+		return
+	}
+	m.undoStack = append(m.undoStack, entry)
+	if len(m.undoStack) > undoLimit {
+		m.undoStack = m.undoStack[len(m.undoStack)-undoLimit:]
+	}
+	m.redoStack = nil
+}
+
+// undo takes back the last save, or with redo makes the last undone one
+// again.
+func (m *Model) undo(redo bool) tea.Cmd {
+	stack := &m.undoStack
+	if redo {
+		stack = &m.redoStack
+	}
+	if len(*stack) == 0 {
+		if redo {
+			m.notice = "Nothing to redo"
+		} else {
+			m.notice = "Nothing to undo"
+		}
+		return nil
+	}
+	entry := (*stack)[len(*stack)-1]
+	*stack = (*stack)[:len(*stack)-1]
+	m.notice = ""
+	m.mode = loading
+	return undoCmd(m.backend, entry.Inverse(), redo)
+}
+
+// undone files what an undo or redo did on the other stack, so it can be
+// reversed in turn.
+func (m *Model) undone(msg undoneMsg) {
+	// Events that came back were created again under a new id, which the
+	// older entries still refer to by the old one
+	for i, change := range msg.applied.Changes {
+		oldId := msg.entry.Changes[i].After.Id
+		if change.Op == opCreate && oldId != "" && oldId != change.After.Id {
+			m.remapUndo(oldId, change.After.Id)
+		}
+	}
+	verb := "undo"
+	if msg.redo {
+		verb = "redo"
+	}
+	if msg.err != nil {
+		log.Printf("Failed to %s %s: %v\n", verb, msg.entry.Label, msg.err)
+		m.status = fmt.Sprintf("Failed to %s %s: %v", verb, msg.entry.Label, msg.err)
+		if len(msg.applied.Changes) == 0 {
+			// Nothing happened, so it can be tried again
+			if msg.redo {
+				m.redoStack = append(m.redoStack, msg.entry.Inverse())
+			} else {
+				m.undoStack = append(m.undoStack, msg.entry.Inverse())
+			}
+		}
+		return
+	}
+	m.status = ""
+	if msg.redo {
+		m.notice = "Redid: " + msg.entry.Label
+		m.undoStack = append(m.undoStack, msg.applied)
+	} else {
+		m.notice = "Undid: " + msg.entry.Label
+		m.redoStack = append(m.redoStack, msg.applied)
+	}
+}
+
+// remapUndo points the recorded changes of an event that was deleted and
+// created again at its new id.
+func (m *Model) remapUndo(oldId string, newId string) {
+	for _, stack := range [][]UndoEntry{m.undoStack, m.redoStack} {
+		for i := range stack {
+			for j := range stack[i].Changes {
+				change := &stack[i].Changes[j]
+				if change.Before.Id == oldId {
+					change.Before.Id = newId
+				}
+				if change.After.Id == oldId {
+					change.After.Id = newId
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+// undoStep runs one undo or redo to the end, as the TUI would.
+func undoStep(t *testing.T, m *Model, redo bool) {
+	t.Helper()
+	cmd := m.undo(redo)
+	if cmd == nil {
+		t.Fatalf("nothing to undo (redo %v)", redo)
+	}
+	msg := cmd().(undoneMsg)
+	m.undone(msg)
+	if msg.err != nil {
+		t.Fatalf("undo (redo %v): %v", redo, msg.err)
+	}
+}
+
+func memoryEvents(t *testing.T, backend *MemoryBackend) []Event {
+	t.Helper()
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	events, err := backend.ListEvents(start, start.AddDate(0, 1, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return events
+}
+
+func TestUndoRedo(t *testing.T) {
+	start := time.Date(2026, 1, 5, 9, 0, 0, 0, time.UTC)
+	backend := NewMemoryBackend(nil, []Event{{
+		Summary: "Dentist",
+		Start:   DateTime{DateTime: start},
+		End:     DateTime{DateTime: start.Add(time.Hour)},
+	}})
+	m := Model{backend: backend}
+	original := memoryEvents(t, backend)[0]
+
+	// Edit, then delete
+	edited := original
+	edited.Summary = "Dentist2"
+	edited, err := backend.UpdateEvent(edited)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.pushUndo(UndoEntry{Label: "edit", Changes: []Change{{Op: opUpdate, Before: original, After: edited}}})
+	if err := backend.DeleteEvent(edited); err != nil {
+		t.Fatal(err)
+	}
+	m.pushUndo(UndoEntry{Label: "delete", Changes: []Change{{Op: opDelete, Before: edited}}})
+
+	tests := []struct {
+		redo    bool
+		summary string
+	}{
+		// Comes back under a new id, which the edit must follow
+		{false, "Dentist2"},
+		{false, "Dentist"},
+		{true, "Dentist2"},
+		{true, ""},
+		{false, "Dentist2"},
+		{false, "Dentist"},
+	}
+	for i, tt := range tests {
+		undoStep(t, &m, tt.redo)
+		events := memoryEvents(t, backend)
+		var summary string
+		if len(events) > 1 {
+			t.Fatalf("step %d: %d events, want at most 1", i, len(events))
+		}
+		if len(events) == 1 {
+			summary = events[0].Summary
+		}
+		if summary != tt.summary {
+			t.Errorf("step %d: summary %q, want %q", i, summary, tt.summary)
+		}
+	}
+	if cmd := m.undo(false); cmd != nil {
+		t.Errorf("undo stack should be empty, has %d entries", len(m.undoStack)+1)
+	}
+}
+
+func TestUndoRestoresEmptyFields(t *testing.T) {
+	start := time.Date(2026, 1, 5, 9, 0, 0, 0, time.UTC)
+	backend := NewMemoryBackend(nil, []Event{{
+		Summary: "Dentist",
+		Start:   DateTime{DateTime: start},
+		End:     DateTime{DateTime: start.Add(time.Hour)},
+	}})
+	m := Model{backend: backend}
+	original := memoryEvents(t, backend)[0]
+
+	edited := original
+	edited.Location = "Main St 4"
+	edited.Description = "Bring the card"
+	edited, err := backend.UpdateEvent(edited)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.pushUndo(UndoEntry{Label: "edit", Changes: []Change{{Op: opUpdate, Before: original, After: edited}}})
+
+	undoStep(t, &m, false)
+	restored := memoryEvents(t, backend)[0]
+	if restored.Location != "" || restored.Description != "" {
+		t.Errorf("undo left location %q and description %q", restored.Location, restored.Description)
+	}
+	// Google keeps fields a PATCH leaves out, so the empty ones must be sent
+	undo := m.redoStack[0].Changes[0].After
+	payload, err := json.Marshal(NewPatchEvent(undo))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fields map[string]any
+	if err := json.Unmarshal(payload, &fields); err != nil {
+		t.Fatal(err)
+	}
+	for _, field := range []string{"location", "description"} {
+		if value, ok := fields[field]; !ok || value != "" {
+			t.Errorf("undo patch sends %s as %v, want it cleared", field, value)
+		}
+	}
+}
+
+func TestChangeInverse(t *testing.T) {
+	before := Event{Id: "a", Summary: "Before"}
+	after := Event{Id: "a", Summary: "After", SendUpdates: "all"}
+	tests := []struct {
+		change Change
+		op     string
+	}{
+		{Change{Op: opCreate, After: after}, opDelete},
+		{Change{Op: opDelete, Before: before}, opCreate},
+		{Change{Op: opUpdate, Before: before, After: after}, opUpdate},
+		{Change{Op: opMove, Before: before, After: after}, opMove},
+	}
+	for _, tt := range tests {
+		inverse := tt.change.Inverse()
+		if inverse.Op != tt.op {
+			t.Errorf("%s inverse is %s, want %s", tt.change.Op, inverse.Op, tt.op)
+		}
+		if inverse.Before.Summary != tt.change.After.Summary || inverse.After.Summary != tt.change.Before.Summary {
+			t.Errorf("%s inverse did not swap before and after: %+v", tt.change.Op, inverse)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxWindowDays is the most days the grid has room for.
+const maxWindowDays = 14
+
+var (
+	// windowDays is how many days the views show, set with WINDOW_DAYS.
+	windowDays = 7
+	// weekStart is the day the window containing today starts on: "today",
+	// "monday" or "sunday", set with WEEK_START.
+	weekStart = "today"
+	// windowPage is how many windows away from the one containing today the
+	// views are, negative for the past.
+	windowPage int
+)
+
+// ParseWindowDays reads WINDOW_DAYS, a number of days from 1 to 14.
+func ParseWindowDays(value string) (int, error) {
+	days, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || days < 1 || days > maxWindowDays {
+		return 0, fmt.Errorf("%q is not a number of days from 1 to %d", value, maxWindowDays)
+	}
+	return days, nil
+}
+
+// ParseWeekStart reads WEEK_START: today, monday or sunday.
+func ParseWeekStart(value string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "", "today":
+		return "today", nil
+	case "monday", "mon":
+		return "monday", nil
+	case "sunday", "sun":
+		return "sunday", nil
+	}
+	return "", fmt.Errorf("%q is not today, monday or sunday", value)
+}
+
+// HomeWindowStart is the first day of the window containing today.
+func HomeWindowStart() time.Time {
+	today := StartOfDay(Now())
+	switch weekStart {
+	case "monday":
+		return today.AddDate(0, 0, -((int(today.Weekday()) + 6) % 7))
+	case "sunday":
+		return today.AddDate(0, 0, -int(today.Weekday()))
+	}
+	return today
+}
+
+// WindowStart is the first day on screen.
+func WindowStart() time.Time {
+	return HomeWindowStart().AddDate(0, 0, windowPage*windowDays)
+}
+
+func WindowDays() int {
+	return windowDays
+}
+
+// TodayIndex is the column of today in the window, outside 0 to
+// WindowDays()-1 when today is not on screen.
+func TodayIndex() int {
+	return DaysBetween(WindowStart(), Now())
+}
+
+// SetWindowPage moves the views page windows away from today's.
+func SetWindowPage(page int) {
+	windowPage = page
+}
+
+func WindowPage() int {
+	return windowPage
+}
+
+// PageOf is the page of the window containing day.
+func PageOf(day time.Time) int {
+	offset := DaysBetween(HomeWindowStart(), day)
+	page := offset / windowDays
+	if offset < 0 && offset%windowDays != 0 {
+		page--
+	}
+	return page
+}
+
+// WindowTitle names the days on screen, like "Mon 19 Oct - Sun 25 Oct 2026".
+func WindowTitle() string {
+	start := WindowStart()
+	end := start.AddDate(0, 0, windowDays-1)
+	if windowDays == 1 {
+		return start.Format("Mon 2 Jan 2006")
+	}
+	if start.Year() != end.Year() {
+		return start.Format("Mon 2 Jan 2006") + " - " + end.Format("Mon 2 Jan 2006")
+	}
+	return start.Format("Mon 2 Jan") + " - " + end.Format("Mon 2 Jan 2006")
+}